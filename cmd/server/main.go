@@ -3,6 +3,7 @@ package main
 import (
 	"devops-go-vault-api/config"
 	"devops-go-vault-api/internal/handler"
+	"devops-go-vault-api/internal/vault"
 	"log"
 	"net/http"
 
@@ -12,14 +13,43 @@ import (
 func main() {
 	config.LoadConfig()
 
+	client, err := vault.GetClient()
+	if err != nil {
+		log.Fatalf("Erro ao autenticar no Vault: %v", err)
+	}
+
+	if mounts, err := vault.DetectMounts(client); err != nil {
+		log.Printf("Não foi possível listar os mounts KV (serão detectados sob demanda): %v", err)
+	} else {
+		for _, mount := range mounts {
+			log.Printf("Mount KV v%d detectado: %s", mount.Version, mount.Path)
+		}
+	}
+
 	router := mux.NewRouter()
 	router.HandleFunc("/sendVault", handler.StoreHandler).Methods("POST")
+	router.HandleFunc("/readSecret", handler.ReadSecretHandler).Methods("GET")
 	router.HandleFunc("/convert", handler.ConvertHandler).Methods("POST")
 	router.HandleFunc("/decSecret", handler.DecryptSecretHandler).Methods("POST")
 	router.HandleFunc("/generate", handler.GenerateHandler).Methods("POST")
 	router.HandleFunc("/deleteSecret", handler.DeleteSecretHandler).Methods("DELETE")
 	router.HandleFunc("/jsonToVaultJson", handler.GenerateSecretHandler).Methods("POST")
 	router.HandleFunc("/updatePassword", handler.UpdatePasswordHandler).Methods("POST")
+	router.HandleFunc("/searchSecrets", handler.SearchSecretsHandler).Methods("POST")
+	router.HandleFunc("/rewriteValues", handler.RewriteValuesHandler).Methods("POST")
+	router.HandleFunc("/rotateCredential", handler.RotateCredentialHandler).Methods("POST")
+	router.HandleFunc("/reuseReport", handler.ReuseReportHandler).Methods("POST")
+	router.HandleFunc("/auditSecrets", handler.AuditSecretsHandler).Methods("POST")
+	router.HandleFunc("/exportSecrets", handler.ExportSecretsHandler).Methods("POST")
+	router.HandleFunc("/importSecrets", handler.ImportSecretsHandler).Methods("POST")
+	router.HandleFunc("/compareSecrets", handler.CompareSecretsHandler).Methods("POST")
+	router.HandleFunc("/promoteSecrets", handler.PromoteSecretsHandler).Methods("POST")
+	router.HandleFunc("/jobs", handler.ListJobsHandler).Methods("GET")
+	router.HandleFunc("/jobs/{id}", handler.GetJobHandler).Methods("GET")
+	router.HandleFunc("/jobs/{id}/events", handler.JobEventsHandler).Methods("GET")
+	router.HandleFunc("/jobs/{id}/cancel", handler.CancelJobHandler).Methods("POST")
+	router.HandleFunc("/runs/{id}", handler.GetRunHandler).Methods("GET")
+	router.HandleFunc("/runs/{id}/rollback", handler.RollbackRunHandler).Methods("POST")
 
 	log.Printf("Iniciando servidor na porta 8080...")
 	log.Fatal(http.ListenAndServe(":8080", router))
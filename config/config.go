@@ -3,6 +3,8 @@ package config
 import (
 	"log"
 	"os"
+	"strconv"
+	"strings"
 
 	"github.com/joho/godotenv"
 )
@@ -10,19 +12,124 @@ import (
 var VaultToken string
 var VaultAddress string
 
+var VaultAuthMethod string
+var VaultAuthMount string
+var VaultRoleID string
+var VaultSecretID string
+var VaultK8sRole string
+var VaultK8sJWTPath string
+var VaultUsername string
+var VaultPassword string
+
+var VaultTokenPassthrough bool
+
+var VaultKVMount string
+
+var VaultWalkWorkers int
+var VaultWalkRateLimit float64
+
+// VaultAPIHMACKey é a chave usada para comparar valores por HMAC-SHA256; vazia desativa o recurso.
+var VaultAPIHMACKey string
+
+// VaultPasswordPolicy é a password policy do Vault usada por padrão ao gerar senhas.
+var VaultPasswordPolicy string
+
+// VaultProtectedPaths são prefixos que nenhuma operação da API pode alterar ou apagar.
+var VaultProtectedPaths []string
+
+// DBPostgresSSLMode é o sslmode das conexões PostgreSQL abertas na rotação de credenciais.
+var DBPostgresSSLMode string
+
 func LoadConfig() {
 	err := godotenv.Load()
 	if err != nil {
 		log.Fatalf("Erro ao Carregar Arquivo .env: %v", err)
 	}
 
-	VaultToken = os.Getenv("VAULT_TOKEN")
-	if VaultToken == "" {
-		log.Fatalf("VAULT_TOKEN não foi definido no arquivo .env")
-	}
-
 	VaultAddress = os.Getenv("VAULT_ADDRESS")
 	if VaultAddress == "" {
 		log.Fatalf("VAULT_ADDRESS não foi definido no arquivo .env")
 	}
+
+	VaultAuthMethod = strings.ToLower(os.Getenv("VAULT_AUTH_METHOD"))
+	if VaultAuthMethod == "" {
+		VaultAuthMethod = "token"
+	}
+
+	VaultAuthMount = os.Getenv("VAULT_AUTH_MOUNT")
+	if VaultAuthMount == "" && VaultAuthMethod != "token" {
+		VaultAuthMount = VaultAuthMethod
+	}
+
+	switch VaultAuthMethod {
+	case "token":
+		VaultToken = os.Getenv("VAULT_TOKEN")
+		if VaultToken == "" {
+			log.Fatalf("VAULT_TOKEN não foi definido no arquivo .env")
+		}
+	case "approle":
+		VaultRoleID = os.Getenv("VAULT_ROLE_ID")
+		VaultSecretID = os.Getenv("VAULT_SECRET_ID")
+		if VaultRoleID == "" || VaultSecretID == "" {
+			log.Fatalf("VAULT_ROLE_ID e VAULT_SECRET_ID são necessários para o método approle")
+		}
+	case "kubernetes":
+		VaultK8sRole = os.Getenv("VAULT_K8S_ROLE")
+		if VaultK8sRole == "" {
+			log.Fatalf("VAULT_K8S_ROLE é necessário para o método kubernetes")
+		}
+		VaultK8sJWTPath = os.Getenv("VAULT_K8S_JWT_PATH")
+		if VaultK8sJWTPath == "" {
+			VaultK8sJWTPath = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+		}
+	case "userpass":
+		VaultUsername = os.Getenv("VAULT_USERNAME")
+		VaultPassword = os.Getenv("VAULT_PASSWORD")
+		if VaultUsername == "" || VaultPassword == "" {
+			log.Fatalf("VAULT_USERNAME e VAULT_PASSWORD são necessários para o método userpass")
+		}
+	default:
+		log.Fatalf("VAULT_AUTH_METHOD inválido: %s (use token, approle, kubernetes ou userpass)", VaultAuthMethod)
+	}
+
+	VaultTokenPassthrough = strings.ToLower(os.Getenv("VAULT_TOKEN_PASSTHROUGH")) == "true"
+
+	VaultKVMount = strings.Trim(os.Getenv("VAULT_KV_MOUNT"), "/")
+	if VaultKVMount == "" {
+		VaultKVMount = "secret"
+	}
+
+	VaultWalkWorkers = 8
+	if raw := os.Getenv("VAULT_WALK_WORKERS"); raw != "" {
+		VaultWalkWorkers, err = strconv.Atoi(raw)
+		if err != nil || VaultWalkWorkers < 1 {
+			log.Fatalf("VAULT_WALK_WORKERS inválido: %s", raw)
+		}
+	}
+
+	if raw := os.Getenv("VAULT_WALK_RATE_LIMIT"); raw != "" {
+		VaultWalkRateLimit, err = strconv.ParseFloat(raw, 64)
+		if err != nil || VaultWalkRateLimit < 0 {
+			log.Fatalf("VAULT_WALK_RATE_LIMIT inválido: %s", raw)
+		}
+	}
+
+	VaultAPIHMACKey = os.Getenv("VAULT_API_HMAC_KEY")
+
+	VaultPasswordPolicy = os.Getenv("VAULT_PASSWORD_POLICY")
+
+	for _, prefix := range strings.Split(os.Getenv("VAULT_PROTECTED_PATHS"), ",") {
+		if prefix = strings.Trim(strings.TrimSpace(prefix), "/"); prefix != "" {
+			VaultProtectedPaths = append(VaultProtectedPaths, prefix)
+		}
+	}
+
+	DBPostgresSSLMode = strings.ToLower(os.Getenv("DB_POSTGRES_SSLMODE"))
+	switch DBPostgresSSLMode {
+	case "":
+		DBPostgresSSLMode = "require"
+	case "disable", "require", "verify-ca", "verify-full":
+	default:
+		log.Fatalf("DB_POSTGRES_SSLMODE inválido: %s (use disable, require, verify-ca ou verify-full)", DBPostgresSSLMode)
+	}
 }
@@ -0,0 +1,213 @@
+package bundle
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"devops-go-vault-api/internal/vault"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/scrypt"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	FormatJSON = "json"
+	FormatYAML = "yaml"
+
+	// LayoutBundle gera o bundle completo; LayoutEntries só a lista de {path, data}
+	LayoutBundle  = "bundle"
+	LayoutEntries = "entries"
+
+	envelopeFormat      = "vault-bundle+aes-256-gcm"
+	minPassphraseLength = 12
+
+	// Parâmetros recomendados do scrypt para uso interativo
+	scryptN = 1 << 15
+	scryptR = 8
+	scryptP = 1
+)
+
+var ErrWrongPassphrase = errors.New("wrong passphrase or corrupted bundle")
+
+// Envelope guarda o bundle cifrado com AES-256-GCM; a chave vem da passphrase via scrypt.
+type Envelope struct {
+	Format      string `json:"format"`
+	ContentType string `json:"content_type"`
+	KDF         string `json:"kdf"`
+	N           int    `json:"n"`
+	R           int    `json:"r"`
+	P           int    `json:"p"`
+	Salt        []byte `json:"salt"`
+	Nonce       []byte `json:"nonce"`
+	Ciphertext  []byte `json:"ciphertext"`
+}
+
+func ParseFormat(format string) (string, error) {
+	switch strings.ToLower(format) {
+	case "", FormatJSON:
+		return FormatJSON, nil
+	case FormatYAML, "yml":
+		return FormatYAML, nil
+	default:
+		return "", fmt.Errorf("invalid format '%s' (use json or yaml)", format)
+	}
+}
+
+func ParseLayout(layout string) (string, error) {
+	switch strings.ToLower(layout) {
+	case "", LayoutBundle:
+		return LayoutBundle, nil
+	case LayoutEntries:
+		return LayoutEntries, nil
+	default:
+		return "", fmt.Errorf("invalid layout '%s' (use bundle or entries)", layout)
+	}
+}
+
+func Encode(b *vault.Bundle, format, layout string) ([]byte, error) {
+	var v interface{} = b
+	if layout == LayoutEntries {
+		v = b.Entries
+	}
+
+	if format == FormatYAML {
+		return yaml.Marshal(v)
+	}
+	return json.MarshalIndent(v, "", "  ")
+}
+
+func Decode(raw []byte, format string) (*vault.Bundle, error) {
+	var b vault.Bundle
+	var err error
+	if format == FormatYAML {
+		err = yaml.Unmarshal(raw, &b)
+	} else {
+		err = json.Unmarshal(raw, &b)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid bundle: %v", err)
+	}
+	return &b, nil
+}
+
+func ValidatePassphrase(passphrase string) error {
+	if len(passphrase) < minPassphraseLength {
+		return fmt.Errorf("passphrase must have at least %d characters", minPassphraseLength)
+	}
+	return nil
+}
+
+// Parse aceita o que /exportSecrets gera (JSON, YAML ou envelope cifrado) e também
+// uma lista simples de {path, data}, o formato de /sendVault.
+func Parse(raw []byte, passphrase string) (*vault.Bundle, error) {
+	format := FormatYAML
+	if IsEncrypted(raw) {
+		if passphrase == "" {
+			return nil, fmt.Errorf("bundle is encrypted: passphrase is required")
+		}
+
+		var err error
+		raw, format, err = Decrypt(raw, passphrase)
+		if err != nil {
+			return nil, err
+		}
+	} else if trimmed := bytes.TrimSpace(raw); len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') {
+		format = FormatJSON
+	}
+
+	var entries []vault.BundleEntry
+	var err error
+	if format == FormatJSON {
+		err = json.Unmarshal(raw, &entries)
+	} else {
+		err = yaml.Unmarshal(raw, &entries)
+	}
+	if err == nil {
+		return &vault.Bundle{Version: 1, Entries: entries}, nil
+	}
+
+	return Decode(raw, format)
+}
+
+// Encrypt cifra o bundle já serializado e devolve o envelope em JSON.
+func Encrypt(plaintext []byte, contentType, passphrase string) ([]byte, error) {
+	if err := ValidatePassphrase(passphrase); err != nil {
+		return nil, err
+	}
+
+	envelope := Envelope{
+		Format:      envelopeFormat,
+		ContentType: contentType,
+		KDF:         "scrypt",
+		N:           scryptN,
+		R:           scryptR,
+		P:           scryptP,
+		Salt:        make([]byte, 16),
+	}
+	if _, err := rand.Read(envelope.Salt); err != nil {
+		return nil, err
+	}
+
+	gcm, err := newGCM(passphrase, envelope)
+	if err != nil {
+		return nil, err
+	}
+
+	envelope.Nonce = make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(envelope.Nonce); err != nil {
+		return nil, err
+	}
+	envelope.Ciphertext = gcm.Seal(nil, envelope.Nonce, plaintext, []byte(envelope.ContentType))
+
+	return json.MarshalIndent(envelope, "", "  ")
+}
+
+// IsEncrypted indica se raw é um envelope gerado por Encrypt.
+func IsEncrypted(raw []byte) bool {
+	var envelope Envelope
+	return json.Unmarshal(raw, &envelope) == nil && envelope.Format == envelopeFormat
+}
+
+// Decrypt devolve o bundle serializado e o formato em que foi gravado.
+func Decrypt(raw []byte, passphrase string) ([]byte, string, error) {
+	var envelope Envelope
+	if err := json.Unmarshal(raw, &envelope); err != nil || envelope.Format != envelopeFormat {
+		return nil, "", fmt.Errorf("not an encrypted bundle")
+	}
+	if envelope.KDF != "scrypt" {
+		return nil, "", fmt.Errorf("unsupported kdf '%s'", envelope.KDF)
+	}
+	// Limita o custo do scrypt lido do próprio envelope
+	if envelope.N > 1<<20 || envelope.R > 32 || envelope.P > 16 {
+		return nil, "", fmt.Errorf("scrypt parameters too expensive")
+	}
+
+	gcm, err := newGCM(passphrase, envelope)
+	if err != nil {
+		return nil, "", err
+	}
+
+	plaintext, err := gcm.Open(nil, envelope.Nonce, envelope.Ciphertext, []byte(envelope.ContentType))
+	if err != nil {
+		return nil, "", ErrWrongPassphrase
+	}
+	return plaintext, envelope.ContentType, nil
+}
+
+func newGCM(passphrase string, envelope Envelope) (cipher.AEAD, error) {
+	key, err := scrypt.Key([]byte(passphrase), envelope.Salt, envelope.N, envelope.R, envelope.P, 32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive key: %v", err)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
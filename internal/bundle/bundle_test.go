@@ -0,0 +1,156 @@
+package bundle
+
+import (
+	"devops-go-vault-api/internal/vault"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+const testPassphrase = "uma-frase-longa-e-secreta"
+
+func testBundle() *vault.Bundle {
+	return &vault.Bundle{
+		Version:   1,
+		Source:    "secret/hml",
+		CreatedAt: time.Date(2024, 6, 10, 12, 0, 0, 0, time.UTC),
+		Entries: []vault.BundleEntry{
+			{
+				Path:           "secret/hml/app",
+				Data:           map[string]interface{}{"DB_PASSWORD": "senha"},
+				CustomMetadata: map[string]interface{}{"owner": "dba"},
+			},
+		},
+	}
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	plaintext := []byte("version: 1\n")
+
+	sealed, err := Encrypt(plaintext, FormatYAML, testPassphrase)
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+	if !IsEncrypted(sealed) {
+		t.Fatal("IsEncrypted = false for an envelope")
+	}
+
+	opened, format, err := Decrypt(sealed, testPassphrase)
+	if err != nil {
+		t.Fatalf("Decrypt: %v", err)
+	}
+	if string(opened) != string(plaintext) || format != FormatYAML {
+		t.Errorf("Decrypt = %q (%s), want %q (yaml)", opened, format, plaintext)
+	}
+
+	if _, _, err := Decrypt(sealed, "outra-frase-longa"); !errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("Decrypt with the wrong passphrase = %v, want ErrWrongPassphrase", err)
+	}
+}
+
+func TestEncryptRejectsShortPassphrase(t *testing.T) {
+	if _, err := Encrypt([]byte("{}"), FormatJSON, "curta"); err == nil {
+		t.Error("Encrypt accepted a short passphrase")
+	}
+}
+
+func TestDecryptRejectsExpensiveParameters(t *testing.T) {
+	sealed := []byte(`{"format":"vault-bundle+aes-256-gcm","kdf":"scrypt","n":4194304,"r":8,"p":1}`)
+	if _, _, err := Decrypt(sealed, testPassphrase); err == nil {
+		t.Error("Decrypt accepted scrypt parameters above the limit")
+	}
+}
+
+func TestEncodeLayouts(t *testing.T) {
+	b := testBundle()
+
+	full, err := Encode(b, FormatJSON, LayoutBundle)
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	var wrapped vault.Bundle
+	if err := json.Unmarshal(full, &wrapped); err != nil || wrapped.Source != "secret/hml" || len(wrapped.Entries) != 1 {
+		t.Errorf("bundle layout = %s (%v)", full, err)
+	}
+
+	// O layout entries é a lista que /sendVault aceita
+	bare, err := Encode(b, FormatJSON, LayoutEntries)
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	var entries []map[string]interface{}
+	if err := json.Unmarshal(bare, &entries); err != nil || len(entries) != 1 || entries[0]["path"] != "secret/hml/app" {
+		t.Errorf("entries layout = %s (%v)", bare, err)
+	}
+}
+
+func TestParse(t *testing.T) {
+	want := testBundle()
+
+	jsonBundle, _ := Encode(want, FormatJSON, LayoutBundle)
+	yamlBundle, _ := Encode(want, FormatYAML, LayoutBundle)
+	jsonEntries, _ := Encode(want, FormatJSON, LayoutEntries)
+	yamlEntries, _ := Encode(want, FormatYAML, LayoutEntries)
+	encrypted, err := Encrypt(yamlBundle, FormatYAML, testPassphrase)
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		raw        []byte
+		passphrase string
+		source     string
+		wantErr    bool
+	}{
+		{name: "json bundle", raw: jsonBundle, source: "secret/hml"},
+		{name: "yaml bundle", raw: yamlBundle, source: "secret/hml"},
+		{name: "json entries", raw: jsonEntries},
+		{name: "yaml entries", raw: yamlEntries},
+		{name: "sendVault list", raw: []byte(`[{"path": "secret/hml/app", "data": {"DB_PASSWORD": "senha"}, "custom_metadata": {"owner": "dba"}}]`)},
+		{name: "encrypted", raw: encrypted, passphrase: testPassphrase, source: "secret/hml"},
+		{name: "encrypted without passphrase", raw: encrypted, wantErr: true},
+		{name: "encrypted with wrong passphrase", raw: encrypted, passphrase: "outra-frase-longa", wantErr: true},
+		{name: "garbage", raw: []byte(`{"entries": 1}`), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.raw, tt.passphrase)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got.Source != tt.source {
+				t.Errorf("Source = %q, want %q", got.Source, tt.source)
+			}
+			if !reflect.DeepEqual(got.Entries, want.Entries) {
+				t.Errorf("Entries = %+v, want %+v", got.Entries, want.Entries)
+			}
+		})
+	}
+}
+
+func TestParseFormatAndLayout(t *testing.T) {
+	for input, want := range map[string]string{"": FormatJSON, "JSON": FormatJSON, "yml": FormatYAML, "yaml": FormatYAML} {
+		if got, err := ParseFormat(input); err != nil || got != want {
+			t.Errorf("ParseFormat(%q) = %q, %v, want %q", input, got, err, want)
+		}
+	}
+	if _, err := ParseFormat("xml"); err == nil {
+		t.Error("ParseFormat accepted xml")
+	}
+
+	for input, want := range map[string]string{"": LayoutBundle, "bundle": LayoutBundle, "Entries": LayoutEntries} {
+		if got, err := ParseLayout(input); err != nil || got != want {
+			t.Errorf("ParseLayout(%q) = %q, %v, want %q", input, got, err, want)
+		}
+	}
+	if _, err := ParseLayout("flat"); err == nil {
+		t.Error("ParseLayout accepted flat")
+	}
+}
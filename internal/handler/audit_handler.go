@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"devops-go-vault-api/config"
+	"devops-go-vault-api/internal/vault"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+type AuditRequest struct {
+	BasePath      string   `json:"base_path"`
+	KeyGlob       string   `json:"key_glob,omitempty"`
+	MinLength     int      `json:"min_length,omitempty"`
+	MinClasses    *int     `json:"min_classes,omitempty"`
+	MinEntropy    float64  `json:"min_entropy,omitempty"`
+	ExtraDefaults []string `json:"extra_defaults,omitempty"`
+
+	vault.PathFilter
+
+	IncludeEvents bool `json:"include_events,omitempty"`
+}
+
+type AuditResponse struct {
+	Success bool               `json:"success"`
+	Message string             `json:"message,omitempty"`
+	Report  *vault.AuditReport `json:"report,omitempty"`
+	Events  []vault.Event      `json:"events,omitempty"`
+}
+
+func AuditSecretsHandler(w http.ResponseWriter, r *http.Request) {
+	var req AuditRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Erro ao decodificar a solicitação JSON", http.StatusBadRequest)
+		return
+	}
+
+	if req.BasePath == "" {
+		req.BasePath = config.VaultKVMount
+	}
+
+	if req.MinLength < 0 || req.MinEntropy < 0 {
+		http.Error(w, "min_length e min_entropy não podem ser negativos", http.StatusBadRequest)
+		return
+	}
+
+	// Omitido usa o padrão; informado, precisa estar entre 1 e 4
+	minClasses := 0
+	if req.MinClasses != nil {
+		minClasses = *req.MinClasses
+		if minClasses < 1 || minClasses > 4 {
+			http.Error(w, "min_classes deve estar entre 1 e 4", http.StatusBadRequest)
+			return
+		}
+	}
+
+	if err := req.PathFilter.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	client, ok := vaultClient(w, r)
+	if !ok {
+		return
+	}
+
+	collector := &vault.Collector{}
+	observer := vault.MultiObserver(collector, vault.LogObserver{Operation: "auditSecrets"})
+
+	report, err := vault.AuditSecrets(r.Context(), client, vault.AuditOptions{
+		BasePath:      strings.TrimSuffix(req.BasePath, "/"),
+		Filter:        req.PathFilter,
+		KeyGlob:       req.KeyGlob,
+		MinLength:     req.MinLength,
+		MinClasses:    minClasses,
+		MinEntropy:    req.MinEntropy,
+		ExtraDefaults: req.ExtraDefaults,
+	}, observer)
+
+	response := AuditResponse{Report: report}
+	if req.IncludeEvents {
+		response.Events = collector.Events()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if err != nil {
+		response.Message = fmt.Sprintf("Erro ao auditar os segredos: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(response)
+		return
+	}
+
+	response.Success = true
+	response.Message = fmt.Sprintf("%d chaves com violações em %d valores verificados", len(report.Findings), report.Checked)
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,203 @@
+package handler
+
+import (
+	"devops-go-vault-api/config"
+	"devops-go-vault-api/internal/bundle"
+	"devops-go-vault-api/internal/vault"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+)
+
+type ExportRequest struct {
+	BasePath   string `json:"base_path"`
+	Format     string `json:"format,omitempty"`
+	Passphrase string `json:"passphrase,omitempty"`
+	Layout     string `json:"layout,omitempty"`
+
+	// AllowPartial aceita um bundle sem os caminhos que não puderam ser lidos
+	AllowPartial bool `json:"allow_partial,omitempty"`
+
+	vault.PathFilter
+}
+
+type ExportFailureResponse struct {
+	Success  bool                `json:"success"`
+	Message  string              `json:"message"`
+	Failures []vault.ScanFailure `json:"failures"`
+}
+
+// ExportSecretsHandler devolve a subárvore como um arquivo JSON ou YAML; com passphrase,
+// o arquivo é um envelope cifrado com AES-256-GCM.
+func ExportSecretsHandler(w http.ResponseWriter, r *http.Request) {
+	var req ExportRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Erro ao decodificar a solicitação JSON", http.StatusBadRequest)
+		return
+	}
+
+	if req.BasePath == "" {
+		req.BasePath = config.VaultKVMount
+	}
+	req.BasePath = strings.TrimSuffix(req.BasePath, "/")
+
+	format, err := bundle.ParseFormat(req.Format)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	layout, err := bundle.ParseLayout(req.Layout)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := req.PathFilter.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if req.Passphrase != "" {
+		if err := bundle.ValidatePassphrase(req.Passphrase); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	client, ok := vaultClient(w, r)
+	if !ok {
+		return
+	}
+
+	exported, failures, err := vault.ExportBundle(r.Context(), client, req.BasePath, req.PathFilter, req.AllowPartial, vault.LogObserver{Operation: "exportSecrets"})
+	if errors.Is(err, vault.ErrPartialExport) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(ExportFailureResponse{
+			Message:  fmt.Sprintf("Erro ao exportar segredos: %v (use allow_partial para aceitar um bundle parcial)", err),
+			Failures: failures,
+		})
+		return
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Erro ao exportar segredos: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	body, err := bundle.Encode(exported, format, layout)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Erro ao gerar o bundle: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	filename := strings.ReplaceAll(path.Clean(req.BasePath), "/", "_") + "." + format
+	contentType := "application/json"
+	if format == bundle.FormatYAML {
+		contentType = "application/x-yaml"
+	}
+
+	if req.Passphrase != "" {
+		body, err = bundle.Encrypt(body, format, req.Passphrase)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Erro ao cifrar o bundle: %v", err), http.StatusInternalServerError)
+			return
+		}
+		filename += ".enc.json"
+		contentType = "application/json"
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	if len(failures) > 0 {
+		w.Header().Set("X-Export-Failures", fmt.Sprint(len(failures)))
+	}
+	w.Write(body)
+}
+
+const maxImportSize = 32 << 20
+
+type ImportResponse struct {
+	Success bool                 `json:"success"`
+	Message string               `json:"message"`
+	Results []vault.ImportResult `json:"results"`
+}
+
+// ImportSecretsHandler recebe o bundle no corpo da requisição. A passphrase vem no
+// header X-Bundle-Passphrase; conflict e remap (from:to, repetível) na query string.
+func ImportSecretsHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	policy, err := vault.ParseConflictPolicy(query.Get("conflict"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var remaps []vault.PathRemap
+	for _, raw := range query["remap"] {
+		from, to, ok := strings.Cut(raw, ":")
+		if !ok {
+			http.Error(w, fmt.Sprintf("remap inválido '%s' (use origem:destino)", raw), http.StatusBadRequest)
+			return
+		}
+		remaps = append(remaps, vault.PathRemap{From: from, To: to})
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxImportSize))
+	if err != nil {
+		http.Error(w, "Erro ao ler o bundle", http.StatusBadRequest)
+		return
+	}
+
+	parsed, err := bundle.Parse(body, r.Header.Get("X-Bundle-Passphrase"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(parsed.Entries) == 0 {
+		http.Error(w, "O bundle não contém segredos", http.StatusBadRequest)
+		return
+	}
+
+	client, ok := vaultClient(w, r)
+	if !ok {
+		return
+	}
+
+	results, err := vault.ImportBundle(r.Context(), client, parsed, remaps, policy)
+
+	response := ImportResponse{Results: results}
+	status := http.StatusOK
+
+	switch {
+	case errors.Is(err, vault.ErrBatchInvalid):
+		response.Message = "Nenhum segredo foi gravado: há entradas inválidas no bundle"
+		status = http.StatusBadRequest
+	case errors.Is(err, vault.ErrImportConflict):
+		response.Message = "Nenhum segredo foi gravado: há destinos que já existem (conflict=fail)"
+		status = http.StatusConflict
+	case err != nil:
+		response.Message = fmt.Sprintf("Erro ao importar: %v", err)
+		status = http.StatusInternalServerError
+	default:
+		counts := make(map[vault.ImportStatus]int)
+		for _, result := range results {
+			counts[result.Status]++
+		}
+		response.Success = counts[vault.ImportFailed] == 0 && counts[vault.ImportConflict] == 0
+		response.Message = fmt.Sprintf("%d gravados, %d ignorados, %d em conflito, %d com falha",
+			counts[vault.ImportWritten], counts[vault.ImportSkipped], counts[vault.ImportConflict], counts[vault.ImportFailed])
+		if !response.Success {
+			status = http.StatusMultiStatus
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
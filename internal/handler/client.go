@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"devops-go-vault-api/config"
+	"devops-go-vault-api/internal/vault"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/hashicorp/vault/api"
+)
+
+func callerToken(r *http.Request) string {
+	if token := strings.TrimSpace(r.Header.Get("X-Vault-Token")); token != "" {
+		return token
+	}
+
+	auth := strings.TrimSpace(r.Header.Get("Authorization"))
+	if len(auth) > 7 && strings.EqualFold(auth[:7], "Bearer ") {
+		return strings.TrimSpace(auth[7:])
+	}
+
+	return ""
+}
+
+// Com VAULT_TOKEN_PASSTHROUGH ativo cada requisição usa o token de quem chamou,
+// deixando as políticas do Vault decidirem o que pode ser feito.
+func vaultClient(w http.ResponseWriter, r *http.Request) (*api.Client, bool) {
+	var client *api.Client
+	var err error
+
+	if config.VaultTokenPassthrough {
+		token := callerToken(r)
+		if token == "" {
+			http.Error(w, "Token do Vault ausente (use X-Vault-Token ou Authorization: Bearer)", http.StatusUnauthorized)
+			return nil, false
+		}
+		client, err = vault.ClientWithToken(token)
+	} else {
+		client, err = vault.GetClient()
+	}
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return nil, false
+	}
+
+	return client, true
+}
+
+// jobOwner identifica o dono dos jobs criados ou consultados na requisição. Com
+// VAULT_TOKEN_PASSTHROUGH é o hash do accessor do token de quem chamou (o token nunca é
+// guardado); sem passthrough todas as requisições usam o token do serviço e
+// compartilham os jobs.
+func jobOwner(w http.ResponseWriter, r *http.Request) (string, bool) {
+	if !config.VaultTokenPassthrough {
+		return "", true
+	}
+
+	client, ok := vaultClient(w, r)
+	if !ok {
+		return "", false
+	}
+
+	accessor, err := vault.TokenAccessor(r.Context(), client)
+	if err != nil {
+		status := http.StatusInternalServerError
+		var respErr *api.ResponseError
+		if errors.As(err, &respErr) && (respErr.StatusCode == http.StatusForbidden || respErr.StatusCode == http.StatusUnauthorized) {
+			status = http.StatusUnauthorized
+		}
+		http.Error(w, err.Error(), status)
+		return "", false
+	}
+
+	sum := sha256.Sum256([]byte(accessor))
+	return hex.EncodeToString(sum[:]), true
+}
@@ -1,30 +1,50 @@
 package handler
 
 import (
-	"bytes"
+	"context"
+	"devops-go-vault-api/config"
+	"devops-go-vault-api/internal/jobs"
 	"devops-go-vault-api/internal/vault"
 	"encoding/json"
 	"fmt"
-	"io"
 	"io/ioutil"
-	"log"
 	"net/http"
-	"os"
 	"strings"
 )
 
 type PasswordUpdateRequest struct {
 	BasePath    string `json:"base_path"`
 	OldPassword string `json:"old_password"`
-	NewPassword string `json:"new_password"`
-	Mode        string `json:"mode,omitempty"`
+	// Alternativas a old_password: SHA-256 ou HMAC-SHA256 (hex) da senha antiga
+	OldPasswordSHA256 string `json:"old_password_sha256,omitempty"`
+	OldPasswordHMAC   string `json:"old_password_hmac,omitempty"`
+	NewPassword       string `json:"new_password"`
+	Mode              string `json:"mode,omitempty"`
+	Async             bool   `json:"async,omitempty"`
+
+	// GeneratePassword gera a nova senha no servidor; ReturnPassword a devolve uma única vez
+	GeneratePassword bool                 `json:"generate_password,omitempty"`
+	PasswordPolicy   vault.PasswordPolicy `json:"password_policy,omitempty"`
+	ReturnPassword   bool                 `json:"return_password,omitempty"`
+
+	// include, exclude e max_depth restringem a varredura abaixo de base_path
+	vault.PathFilter
+
+	IncludeEvents bool `json:"include_events,omitempty"`
 }
 
 type PasswordUpdateResponse struct {
-	Success bool                         `json:"success"`
-	Message string                       `json:"message,omitempty"`
-	Mode    string                       `json:"mode"`
+	Success bool   `json:"success"`
+	Message string `json:"message,omitempty"`
+	Mode    string `json:"mode"`
+	JobID   string `json:"job_id,omitempty"`
+	RunID   string `json:"run_id,omitempty"`
+
+	GeneratedPassword string               `json:"generated_password,omitempty"`
+	PasswordSource    vault.PasswordSource `json:"password_source,omitempty"`
+
 	Updates []vault.PasswordUpdateResult `json:"updates,omitempty"`
+	Events  []vault.Event                `json:"events,omitempty"`
 }
 
 func UpdatePasswordHandler(w http.ResponseWriter, r *http.Request) {
@@ -45,18 +65,33 @@ func UpdatePasswordHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if req.OldPassword == "" {
-		http.Error(w, "Senha antiga é obrigatória", http.StatusBadRequest)
+	matcher, err := oldPasswordMatcher(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	if strings.ToLower(req.Mode) == "edit" && req.NewPassword == "" {
+	if err := req.PathFilter.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if req.GeneratePassword {
+		if strings.ToLower(req.Mode) != "edit" {
+			http.Error(w, "generate_password só pode ser usado no modo edit", http.StatusBadRequest)
+			return
+		}
+		if req.NewPassword != "" {
+			http.Error(w, "Informe new_password ou generate_password, não ambos", http.StatusBadRequest)
+			return
+		}
+	} else if strings.ToLower(req.Mode) == "edit" && req.NewPassword == "" {
 		http.Error(w, "Nova senha é obrigatória no modo edit", http.StatusBadRequest)
 		return
 	}
 
 	if req.BasePath == "" {
-		req.BasePath = "secret"
+		req.BasePath = config.VaultKVMount
 	}
 
 	if req.Mode == "" {
@@ -76,21 +111,81 @@ func UpdatePasswordHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	oldStdout := os.Stdout
-	r1, w1, _ := os.Pipe()
-	os.Stdout = w1
+	client, ok := vaultClient(w, r)
+	if !ok {
+		return
+	}
+
+	var generatedPassword string
+	var passwordSource vault.PasswordSource
+	if req.GeneratePassword {
+		req.NewPassword, passwordSource, err = vault.GeneratePassword(r.Context(), client, req.PasswordPolicy)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Erro ao gerar nova senha: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		if req.ReturnPassword {
+			generatedPassword = req.NewPassword
+		}
+	}
+
+	// O mesmo owner é dono do job e do RunLog
+	owner, ok := jobOwner(w, r)
+	if !ok {
+		return
+	}
+
+	// Execuções em modo edit registram as versões anteriores para /runs/{id}/rollback
+	treeOpts := vault.TreeOptions{Filter: req.PathFilter}
+	var runID string
+	if mode == vault.EditMode {
+		treeOpts.RunLog, err = vault.NewRunLog("updatePassword", req.BasePath, owner)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		runID = treeOpts.RunLog.ID
+	}
 
-	updates, err := vault.SearchAndReplacePasswordDirect(req.BasePath, req.OldPassword, req.NewPassword, mode)
+	if req.Async {
+		// A senha gerada só é entregue pelo job, depois que algum segredo for gravado
+		job, err := jobs.Default.StartWithPassword("updatePassword", owner, generatedPassword, func(ctx context.Context, observer vault.Observer) ([]vault.PasswordUpdateResult, error) {
+			return vault.SearchAndReplaceMatching(ctx, client, req.BasePath, matcher, req.NewPassword, mode, observer, treeOpts)
+		})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
 
-	w1.Close()
-	os.Stdout = oldStdout
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Location", "/jobs/"+job.ID)
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(PasswordUpdateResponse{
+			Success: true,
+			Message: fmt.Sprintf("Job %s criado; acompanhe em /jobs/%s", job.ID, job.ID),
+			Mode:    req.Mode,
+			JobID:   job.ID,
+			RunID:   runID,
+
+			PasswordSource: passwordSource,
+		})
+		return
+	}
+
+	collector := &vault.Collector{}
+	observer := vault.MultiObserver(collector, vault.LogObserver{Operation: "updatePassword"})
 
-	var logBuffer bytes.Buffer
-	io.Copy(&logBuffer, r1)
-	log.Print(logBuffer.String())
+	updates, err := vault.SearchAndReplaceMatching(r.Context(), client, req.BasePath, matcher, req.NewPassword, mode, observer, treeOpts)
 
 	response := PasswordUpdateResponse{
-		Mode: req.Mode,
+		Mode:           req.Mode,
+		RunID:          runID,
+		PasswordSource: passwordSource,
+	}
+
+	if req.IncludeEvents {
+		response.Events = collector.Events()
 	}
 
 	if err != nil {
@@ -100,6 +195,13 @@ func UpdatePasswordHandler(w http.ResponseWriter, r *http.Request) {
 	} else {
 		response.Success = true
 		response.Updates = updates
+		// Só devolve a senha gerada se ela foi de fato gravada em algum segredo
+		for _, update := range updates {
+			if update.Error == "" {
+				response.GeneratedPassword = generatedPassword
+				break
+			}
+		}
 		if len(updates) == 0 {
 			response.Message = "Nenhuma senha correspondente encontrada"
 		} else {
@@ -114,3 +216,22 @@ func UpdatePasswordHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 }
+
+// oldPasswordMatcher aceita exatamente uma forma de identificar a senha antiga.
+func oldPasswordMatcher(req PasswordUpdateRequest) (vault.Matcher, error) {
+	hashMatcher, err := vault.NewHashMatcher(req.OldPasswordSHA256, req.OldPasswordHMAC)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case hashMatcher != nil && req.OldPassword != "":
+		return nil, fmt.Errorf("Informe old_password ou o hash da senha antiga, não ambos")
+	case hashMatcher != nil:
+		return hashMatcher, nil
+	case req.OldPassword != "":
+		return vault.ExactValue(req.OldPassword), nil
+	default:
+		return nil, fmt.Errorf("Senha antiga é obrigatória (old_password, old_password_sha256 ou old_password_hmac)")
+	}
+}
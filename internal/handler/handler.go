@@ -2,20 +2,37 @@ package handler
 
 import (
 	"devops-go-vault-api"
+	appconfig "devops-go-vault-api/config"
 	"devops-go-vault-api/internal/converter"
 	"devops-go-vault-api/internal/k8ssecret"
 	"devops-go-vault-api/internal/vault"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"github.com/hashicorp/vault/api"
 	"gopkg.in/yaml.v3"
 	"io/ioutil"
 	"net/http"
+	"strconv"
 	"strings"
 )
 
 type Request struct {
 	Path string            `json:"path"`
 	Data map[string]string `json:"data"`
+	Mode string            `json:"mode,omitempty"`
+	CAS  *int              `json:"cas,omitempty"`
+}
+
+type StoreBatchResponse struct {
+	Success bool                `json:"success"`
+	Message string              `json:"message,omitempty"`
+	Results []vault.BatchResult `json:"results"`
+}
+
+type StorePreviewResponse struct {
+	DryRun bool               `json:"dry_run"`
+	Diffs  []vault.SecretDiff `json:"diffs"`
 }
 
 type SecretRequest struct {
@@ -31,10 +48,17 @@ type GenerateRequest struct {
 	Host        string            `json:"host"`
 	SGBD        string            `json:"sgbd"`
 	Application string            `json:"application"`
+	Mount       string            `json:"mount,omitempty"`
 }
 
 type DeleteSecretRequest struct {
-	Path string `json:"path"`
+	Path     string `json:"path"`
+	Mode     string `json:"mode,omitempty"`
+	Versions []int  `json:"versions,omitempty"`
+	Confirm  string `json:"confirm,omitempty"`
+
+	Recursive         bool   `json:"recursive,omitempty"`
+	ConfirmationToken string `json:"confirmation_token,omitempty"`
 }
 
 type DBConfig struct {
@@ -58,13 +82,50 @@ func StoreHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	client, ok := vaultClient(w, r)
+	if !ok {
+		return
+	}
+
+	if strings.ToLower(r.URL.Query().Get("dry_run")) == "true" {
+		showValues := strings.ToLower(r.URL.Query().Get("show_values")) == "true"
+		response := StorePreviewResponse{
+			DryRun: true,
+			Diffs:  vault.PreviewStore(client, batchItems(requests), showValues),
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+		return
+	}
+
+	if strings.ToLower(r.URL.Query().Get("atomic")) == "true" {
+		storeBatch(w, r, client, requests)
+		return
+	}
+
+	ctx := vault.WithRateLimit(r.Context())
 	for _, req := range requests {
 		if req.Path == "" || len(req.Data) == 0 {
 			http.Error(w, "Path and Data são necessários", http.StatusBadRequest)
 			return
 		}
 
-		err = vault.StoreInVault(req.Path, req.Data)
+		mode, err := vault.ParseWriteMode(req.Mode)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		err = vault.StoreInVaultWithContext(ctx, client, req.Path, req.Data, vault.StoreOptions{Mode: mode, CAS: req.CAS})
+		if errors.Is(err, vault.ErrProtectedPath) {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		if errors.Is(err, vault.ErrSecretExists) || errors.Is(err, vault.ErrCASMismatch) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
@@ -75,6 +136,92 @@ func StoreHandler(w http.ResponseWriter, r *http.Request) {
 	fmt.Fprint(w, "Estrutura criada e Dados Inseridos com Sucesso!")
 }
 
+func batchItems(requests []Request) []vault.BatchItem {
+	items := make([]vault.BatchItem, len(requests))
+	for i, req := range requests {
+		items[i] = vault.BatchItem{
+			Path: req.Path,
+			Data: req.Data,
+			Mode: req.Mode,
+			CAS:  req.CAS,
+		}
+	}
+	return items
+}
+
+func storeBatch(w http.ResponseWriter, r *http.Request, client *api.Client, requests []Request) {
+	results, err := vault.StoreBatch(r.Context(), client, batchItems(requests))
+
+	response := StoreBatchResponse{
+		Success: err == nil,
+		Results: results,
+	}
+
+	status := http.StatusOK
+	switch {
+	case err == nil:
+		response.Message = fmt.Sprintf("%d caminhos gravados com sucesso", len(results))
+	case errors.Is(err, vault.ErrProtectedPath):
+		response.Message = "Lote recusado: há caminhos protegidos (VAULT_PROTECTED_PATHS); nenhum caminho foi gravado"
+		status = http.StatusForbidden
+	case errors.Is(err, vault.ErrBatchInvalid):
+		response.Message = "Lote inválido: nenhum caminho foi gravado"
+		status = http.StatusBadRequest
+	case errors.Is(err, vault.ErrSecretExists) || errors.Is(err, vault.ErrCASMismatch):
+		response.Message = fmt.Sprintf("Conflito ao gravar o lote, caminhos já gravados foram revertidos: %v", err)
+		status = http.StatusConflict
+	default:
+		response.Message = fmt.Sprintf("Erro ao gravar o lote, caminhos já gravados foram revertidos: %v", err)
+		status = http.StatusInternalServerError
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
+
+func ReadSecretHandler(w http.ResponseWriter, r *http.Request) {
+	path := r.URL.Query().Get("path")
+	if path == "" {
+		http.Error(w, "Missing path parameter", http.StatusBadRequest)
+		return
+	}
+
+	version := 0
+	if raw := r.URL.Query().Get("version"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 {
+			http.Error(w, "Invalid version parameter", http.StatusBadRequest)
+			return
+		}
+		version = parsed
+	}
+
+	client, ok := vaultClient(w, r)
+	if !ok {
+		return
+	}
+
+	secret, err := vault.ReadSecret(client, path, version)
+	if errors.Is(err, vault.ErrSecretNotFound) {
+		http.Error(w, fmt.Sprintf("Secret not found at path '%s'", path), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	jsonResponse, err := json.Marshal(secret)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(jsonResponse)
+}
+
 func ConvertHandler(w http.ResponseWriter, r *http.Request) {
 	body, err := ioutil.ReadAll(r.Body)
 	if err != nil {
@@ -179,7 +326,10 @@ func GenerateHandler(w http.ResponseWriter, r *http.Request) {
 
 	lowerSGBD := strings.ToLower(req.SGBD) // SGBD em minúsculas para a URL
 	upperSGBD := strings.ToUpper(req.SGBD) // SGBD em maiúsculas para o prefixo das chaves
-	templatePath := fmt.Sprintf("secret/data/general/dba/%s/%s/%s", lowerSGBD, req.Host, req.Application)
+	if req.Mount == "" {
+		req.Mount = appconfig.VaultKVMount
+	}
+	templatePath := vault.KVDataPath(req.Mount, fmt.Sprintf("general/dba/%s/%s/%s", lowerSGBD, req.Host, req.Application))
 
 	templateOutput := make(map[string]string)
 	for key := range req.DBInfo {
@@ -224,25 +374,71 @@ func DeleteSecretHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	secretList, err := vault.ListSecrets(req.Path)
+	mode, err := vault.ParseDeleteMode(req.Mode)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	client, ok := vaultClient(w, r)
+	if !ok {
+		return
+	}
+
+	if req.Recursive {
+		recursiveDelete(w, r, client, req, mode)
+		return
+	}
+
+	// Apagar os metadados remove todas as versões de forma permanente
+	if mode == vault.MetadataDelete && req.Confirm != req.Path {
+		http.Error(w, "Metadata delete permanently removes every version; send \"confirm\" with the same value as \"path\"", http.StatusBadRequest)
+		return
+	}
+
+	secretList, err := vault.ListSecrets(client, req.Path)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
 	if len(secretList) > 0 {
-		http.Error(w, "Cannot delete a folder with multiple secrets", http.StatusForbidden)
+		http.Error(w, "Cannot delete a folder with multiple secrets (use \"recursive\": true)", http.StatusForbidden)
 		return
 	}
 
-	err = vault.DeleteSecret(req.Path)
+	err = vault.DeleteSecret(client, req.Path, vault.DeleteOptions{Mode: mode, Versions: req.Versions})
+	if errors.Is(err, vault.ErrProtectedPath) {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	if errors.Is(err, vault.ErrKVv1DeleteMode) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, vault.ErrSecretNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	var message string
+	switch mode {
+	case vault.SoftDelete:
+		message = fmt.Sprintf("Secret at path '%s' deleted successfully (recoverable with undelete)", req.Path)
+	case vault.UndeleteMode:
+		message = fmt.Sprintf("Secret at path '%s' undeleted successfully", req.Path)
+	case vault.DestroyDelete:
+		message = fmt.Sprintf("Versions %v of secret at path '%s' destroyed successfully", req.Versions, req.Path)
+	default:
+		message = fmt.Sprintf("Secret at path '%s' and all its versions deleted successfully", req.Path)
+	}
+
 	response := map[string]string{
-		"message": fmt.Sprintf("Secret at path '%s' deleted successfully", req.Path),
+		"message": message,
 	}
 
 	jsonResponse, err := json.Marshal(response)
@@ -275,6 +471,11 @@ func GenerateSecretHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	mount := r.URL.Query().Get("mount")
+	if mount == "" {
+		mount = appconfig.VaultKVMount
+	}
+
 	var result []SecretPayload
 	pathDataMap := make(map[string]map[string]interface{})
 	legacyData := make(map[string]interface{})
@@ -306,7 +507,7 @@ func GenerateSecretHandler(w http.ResponseWriter, r *http.Request) {
 
 		upperDBType := strings.ToUpper(dbType)
 
-		path := fmt.Sprintf("secret/data/general/dba/%s/%s/%s", dbType, host, application)
+		path := vault.KVDataPath(mount, fmt.Sprintf("general/dba/%s/%s/%s", dbType, host, application))
 
 		if _, exists := pathDataMap[path]; !exists {
 			pathDataMap[path] = make(map[string]interface{})
@@ -329,7 +530,7 @@ func GenerateSecretHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	result = append(result, SecretPayload{
-		Path: "secret/data/legacy/" + application,
+		Path: vault.KVDataPath(mount, "legacy/"+application),
 		Data: legacyData,
 	})
 
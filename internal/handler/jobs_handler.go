@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"devops-go-vault-api/internal/jobs"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+const jobEventsInterval = time.Second
+
+func ListJobsHandler(w http.ResponseWriter, r *http.Request) {
+	owner, ok := jobOwner(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(jobs.Default.List(owner))
+}
+
+func GetJobHandler(w http.ResponseWriter, r *http.Request) {
+	owner, ok := jobOwner(w, r)
+	if !ok {
+		return
+	}
+
+	job, ok := jobs.Default.Get(mux.Vars(r)["id"], owner)
+	if !ok {
+		http.Error(w, "Job não encontrado", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(job)
+}
+
+func CancelJobHandler(w http.ResponseWriter, r *http.Request) {
+	owner, ok := jobOwner(w, r)
+	if !ok {
+		return
+	}
+
+	job, ok := jobs.Default.Cancel(mux.Vars(r)["id"], owner)
+	if !ok {
+		http.Error(w, "Job não encontrado", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(job)
+}
+
+// JobEventsHandler envia o progresso do job como Server-Sent Events até ele terminar.
+// A senha gerada, se houver, só é entregue (e consumida) no evento done.
+func JobEventsHandler(w http.ResponseWriter, r *http.Request) {
+	owner, ok := jobOwner(w, r)
+	if !ok {
+		return
+	}
+
+	id := mux.Vars(r)["id"]
+	if _, ok := jobs.Default.Peek(id, owner); !ok {
+		http.Error(w, "Job não encontrado", http.StatusNotFound)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming não suportado", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	ticker := time.NewTicker(jobEventsInterval)
+	defer ticker.Stop()
+
+	for {
+		job, _ := jobs.Default.Peek(id, owner)
+		finished := job.Status != jobs.StatusRunning
+
+		event := "progress"
+		if finished {
+			event = "done"
+			if final, ok := jobs.Default.Get(id, owner); ok {
+				job = final
+			}
+		} else {
+			job.Results = nil
+		}
+
+		payload, _ := json.Marshal(job)
+		fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, payload)
+		flusher.Flush()
+
+		if finished {
+			return
+		}
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
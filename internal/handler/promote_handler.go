@@ -0,0 +1,158 @@
+package handler
+
+import (
+	"devops-go-vault-api/internal/vault"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+type CompareRequest struct {
+	Source           string `json:"source"`
+	Target           string `json:"target"`
+	MaxDepth         int    `json:"max_depth,omitempty"`
+	IncludeIdentical bool   `json:"include_identical,omitempty"`
+}
+
+type CompareResponse struct {
+	Success     bool                   `json:"success"`
+	Message     string                 `json:"message,omitempty"`
+	Comparisons []vault.PathComparison `json:"comparisons"`
+}
+
+type PromoteRequest struct {
+	Source   string   `json:"source"`
+	Target   string   `json:"target"`
+	Keys     []string `json:"keys"`
+	Paths    []string `json:"paths,omitempty"`
+	MaxDepth int      `json:"max_depth,omitempty"`
+	DryRun   bool     `json:"dry_run,omitempty"`
+}
+
+type PromoteResponse struct {
+	Success bool                    `json:"success"`
+	Message string                  `json:"message,omitempty"`
+	DryRun  bool                    `json:"dry_run"`
+	Results []vault.PromotionResult `json:"results"`
+}
+
+// CompareSecretsHandler mostra, por caminho relativo, as chaves que faltam, sobram ou
+// diferem entre dois prefixos, com os valores mascarados.
+func CompareSecretsHandler(w http.ResponseWriter, r *http.Request) {
+	var req CompareRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Erro ao decodificar a solicitação JSON", http.StatusBadRequest)
+		return
+	}
+
+	if req.Source == "" || req.Target == "" {
+		http.Error(w, "source e target são obrigatórios", http.StatusBadRequest)
+		return
+	}
+
+	filter := vault.PathFilter{MaxDepth: req.MaxDepth}
+	if err := filter.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	client, ok := vaultClient(w, r)
+	if !ok {
+		return
+	}
+
+	comparisons, err := vault.ComparePrefixes(r.Context(), client, req.Source, req.Target, filter, req.IncludeIdentical, vault.LogObserver{Operation: "compareSecrets"})
+	if errors.Is(err, vault.ErrOverlappingPrefixes) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Erro ao comparar os prefixos: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(CompareResponse{
+		Success:     true,
+		Message:     fmt.Sprintf("%d caminhos com diferenças", countDifferences(comparisons)),
+		Comparisons: comparisons,
+	})
+}
+
+func countDifferences(comparisons []vault.PathComparison) int {
+	count := 0
+	for _, comparison := range comparisons {
+		if comparison.Status != vault.Identical {
+			count++
+		}
+	}
+	return count
+}
+
+// PromoteSecretsHandler copia do source para o target apenas as chaves em keys.
+func PromoteSecretsHandler(w http.ResponseWriter, r *http.Request) {
+	var req PromoteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Erro ao decodificar a solicitação JSON", http.StatusBadRequest)
+		return
+	}
+
+	if req.Source == "" || req.Target == "" || len(req.Keys) == 0 {
+		http.Error(w, "source, target e keys são obrigatórios", http.StatusBadRequest)
+		return
+	}
+
+	filter := vault.PathFilter{MaxDepth: req.MaxDepth}
+	if err := filter.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	client, ok := vaultClient(w, r)
+	if !ok {
+		return
+	}
+
+	results, err := vault.PromoteKeys(r.Context(), client, vault.PromoteOptions{
+		Source: req.Source,
+		Target: req.Target,
+		Keys:   req.Keys,
+		Paths:  req.Paths,
+		Filter: filter,
+		DryRun: req.DryRun,
+	}, vault.LogObserver{Operation: "promoteSecrets"})
+	if errors.Is(err, vault.ErrOverlappingPrefixes) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Erro ao promover os segredos: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	copied, failed := 0, 0
+	for _, result := range results {
+		copied += len(result.Copied)
+		if result.Error != "" {
+			failed++
+		}
+	}
+
+	response := PromoteResponse{
+		Success: failed == 0,
+		DryRun:  req.DryRun,
+		Results: results,
+	}
+	if req.DryRun {
+		response.Message = fmt.Sprintf("%d chaves seriam copiadas (dry-run)", copied)
+	} else {
+		response.Message = fmt.Sprintf("%d chaves copiadas, %d caminhos com erro", copied, failed)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if failed > 0 {
+		w.WriteHeader(http.StatusMultiStatus)
+	}
+	json.NewEncoder(w).Encode(response)
+}
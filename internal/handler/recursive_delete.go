@@ -0,0 +1,184 @@
+package handler
+
+import (
+	"crypto/rand"
+	"devops-go-vault-api/internal/vault"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+)
+
+const deletePlanTTL = 5 * time.Minute
+
+type deletePlan struct {
+	path      string
+	mode      vault.DeleteMode
+	paths     []string
+	expiresAt time.Time
+}
+
+type RecursiveDeletePlanResponse struct {
+	Path              string    `json:"path"`
+	Mode              string    `json:"mode"`
+	Paths             []string  `json:"paths"`
+	ConfirmationToken string    `json:"confirmation_token"`
+	ExpiresAt         time.Time `json:"expires_at"`
+}
+
+type RecursiveDeleteResponse struct {
+	Success bool                 `json:"success"`
+	Message string               `json:"message"`
+	Mode    string               `json:"mode"`
+	Results []vault.DeleteResult `json:"results"`
+}
+
+var (
+	deletePlansMu sync.Mutex
+	deletePlans   = make(map[string]deletePlan)
+)
+
+func newConfirmationToken() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+func savePlan(plan deletePlan) (string, error) {
+	token, err := newConfirmationToken()
+	if err != nil {
+		return "", err
+	}
+
+	deletePlansMu.Lock()
+	defer deletePlansMu.Unlock()
+
+	now := time.Now()
+	for key, existing := range deletePlans {
+		if now.After(existing.expiresAt) {
+			delete(deletePlans, key)
+		}
+	}
+
+	deletePlans[token] = plan
+	return token, nil
+}
+
+// takePlan consome o token: cada plano só pode ser executado uma vez.
+func takePlan(token string) (deletePlan, bool) {
+	deletePlansMu.Lock()
+	defer deletePlansMu.Unlock()
+
+	plan, ok := deletePlans[token]
+	delete(deletePlans, token)
+
+	if !ok || time.Now().After(plan.expiresAt) {
+		return deletePlan{}, false
+	}
+	return plan, true
+}
+
+func recursiveDelete(w http.ResponseWriter, r *http.Request, client *api.Client, req DeleteSecretRequest, mode vault.DeleteMode) {
+	if mode == vault.DestroyDelete || len(req.Versions) > 0 {
+		http.Error(w, "Recursive delete does not support explicit versions", http.StatusBadRequest)
+		return
+	}
+
+	if req.ConfirmationToken == "" {
+		// O plano de deleção de metadados exige a mesma confirmação da deleção simples
+		if mode == vault.MetadataDelete && req.Confirm != req.Path {
+			http.Error(w, "Metadata delete permanently removes every version; send \"confirm\" with the same value as \"path\"", http.StatusBadRequest)
+			return
+		}
+
+		paths, err := vault.PlanRecursiveDelete(r.Context(), client, req.Path, mode)
+		if errors.Is(err, vault.ErrProtectedPath) {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		if errors.Is(err, vault.ErrKVv1DeleteMode) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if len(paths) == 0 {
+			http.Error(w, fmt.Sprintf("No secrets found under path '%s'", req.Path), http.StatusNotFound)
+			return
+		}
+
+		plan := deletePlan{
+			path:      req.Path,
+			mode:      mode,
+			paths:     paths,
+			expiresAt: time.Now().Add(deletePlanTTL),
+		}
+
+		token, err := savePlan(plan)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(RecursiveDeletePlanResponse{
+			Path:              plan.path,
+			Mode:              string(plan.mode),
+			Paths:             plan.paths,
+			ConfirmationToken: token,
+			ExpiresAt:         plan.expiresAt,
+		})
+		return
+	}
+
+	plan, ok := takePlan(req.ConfirmationToken)
+	if !ok {
+		http.Error(w, "Invalid or expired confirmation token", http.StatusBadRequest)
+		return
+	}
+
+	if plan.path != req.Path || plan.mode != mode {
+		http.Error(w, "Confirmation token does not match the requested path and mode", http.StatusBadRequest)
+		return
+	}
+
+	results, err := vault.DeleteSecrets(r.Context(), client, plan.paths, vault.DeleteOptions{Mode: plan.mode})
+	if errors.Is(err, vault.ErrProtectedPath) {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	failed := 0
+	for _, result := range results {
+		if result.Error != "" {
+			failed++
+		}
+	}
+
+	response := RecursiveDeleteResponse{
+		Success: failed == 0,
+		Mode:    string(plan.mode),
+		Results: results,
+		Message: fmt.Sprintf("%d of %d secrets under '%s' processed successfully", len(results)-failed, len(results), plan.path),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if failed > 0 {
+		w.WriteHeader(http.StatusMultiStatus)
+	}
+	json.NewEncoder(w).Encode(response)
+}
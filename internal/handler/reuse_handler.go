@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"devops-go-vault-api/config"
+	"devops-go-vault-api/internal/vault"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+type ReuseReportRequest struct {
+	BasePath   string `json:"base_path"`
+	KeyGlob    string `json:"key_glob,omitempty"`
+	MinLength  int    `json:"min_length,omitempty"`
+	AppSegment int    `json:"app_segment,omitempty"`
+
+	vault.PathFilter
+
+	IncludeEvents bool `json:"include_events,omitempty"`
+}
+
+type ReuseReportResponse struct {
+	Success bool               `json:"success"`
+	Message string             `json:"message,omitempty"`
+	Report  *vault.ReuseReport `json:"report,omitempty"`
+	Events  []vault.Event      `json:"events,omitempty"`
+}
+
+// ReuseReportHandler agrupa as chaves que compartilham o mesmo valor; os valores
+// nunca aparecem na resposta.
+func ReuseReportHandler(w http.ResponseWriter, r *http.Request) {
+	var req ReuseReportRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Erro ao decodificar a solicitação JSON", http.StatusBadRequest)
+		return
+	}
+
+	if req.BasePath == "" {
+		req.BasePath = config.VaultKVMount
+	}
+
+	if req.MinLength < 0 || req.AppSegment < 0 {
+		http.Error(w, "min_length e app_segment não podem ser negativos", http.StatusBadRequest)
+		return
+	}
+
+	if err := req.PathFilter.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	client, ok := vaultClient(w, r)
+	if !ok {
+		return
+	}
+
+	collector := &vault.Collector{}
+	observer := vault.MultiObserver(collector, vault.LogObserver{Operation: "reuseReport"})
+
+	report, err := vault.FindReusedValues(r.Context(), client, vault.ReuseOptions{
+		BasePath:   strings.TrimSuffix(req.BasePath, "/"),
+		Filter:     req.PathFilter,
+		KeyGlob:    req.KeyGlob,
+		MinLength:  req.MinLength,
+		AppSegment: req.AppSegment,
+	}, observer)
+
+	response := ReuseReportResponse{Report: report}
+	if req.IncludeEvents {
+		response.Events = collector.Events()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if err != nil {
+		response.Message = fmt.Sprintf("Erro ao gerar o relatório: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(response)
+		return
+	}
+
+	response.Success = true
+	response.Message = fmt.Sprintf("%d valores compartilhados entre %d segredos verificados", len(report.Clusters), report.Scanned)
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,152 @@
+package handler
+
+import (
+	"context"
+	"devops-go-vault-api/config"
+	"devops-go-vault-api/internal/jobs"
+	"devops-go-vault-api/internal/vault"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+type RewriteRequest struct {
+	BasePath   string `json:"base_path"`
+	KeyGlob    string `json:"key_glob,omitempty"`
+	MatchType  string `json:"match_type,omitempty"`
+	Find       string `json:"find"`
+	Replace    string `json:"replace"`
+	Mode       string `json:"mode,omitempty"`
+	ShowValues bool   `json:"show_values,omitempty"`
+	Async      bool   `json:"async,omitempty"`
+
+	AllowUnanchored bool `json:"allow_unanchored,omitempty"`
+
+	vault.PathFilter
+
+	IncludeEvents bool `json:"include_events,omitempty"`
+}
+
+func RewriteValuesHandler(w http.ResponseWriter, r *http.Request) {
+	var req RewriteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Erro ao decodificar a solicitação JSON", http.StatusBadRequest)
+		return
+	}
+
+	if req.BasePath == "" {
+		req.BasePath = config.VaultKVMount
+	}
+	if req.Mode == "" {
+		req.Mode = "list"
+	}
+
+	opts := vault.RewriteOptions{
+		BasePath:   strings.TrimSuffix(req.BasePath, "/"),
+		KeyGlob:    req.KeyGlob,
+		MatchType:  vault.MatchType(strings.ToLower(req.MatchType)),
+		Find:       req.Find,
+		Replace:    req.Replace,
+		Mode:       vault.OperationMode(strings.ToLower(req.Mode)),
+		ShowValues: req.ShowValues,
+		Filter:     req.PathFilter,
+
+		AllowUnanchored: req.AllowUnanchored,
+	}
+
+	if opts.Mode != vault.ListMode && opts.Mode != vault.EditMode {
+		http.Error(w, "Modo inválido. Use 'list' ou 'edit'", http.StatusBadRequest)
+		return
+	}
+
+	if _, err := vault.NewRewriter(opts); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := opts.Filter.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	client, ok := vaultClient(w, r)
+	if !ok {
+		return
+	}
+
+	// O mesmo owner é dono do job e do RunLog
+	owner, ok := jobOwner(w, r)
+	if !ok {
+		return
+	}
+
+	var runID string
+	if opts.Mode == vault.EditMode {
+		var err error
+		opts.RunLog, err = vault.NewRunLog("rewriteValues", opts.BasePath, owner)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		runID = opts.RunLog.ID
+	}
+
+	if req.Async {
+		job, err := jobs.Default.Start("rewriteValues", owner, func(ctx context.Context, observer vault.Observer) ([]vault.PasswordUpdateResult, error) {
+			return vault.RewriteValues(ctx, client, opts, observer)
+		})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Location", "/jobs/"+job.ID)
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(PasswordUpdateResponse{
+			Success: true,
+			Message: fmt.Sprintf("Job %s criado; acompanhe em /jobs/%s", job.ID, job.ID),
+			Mode:    req.Mode,
+			JobID:   job.ID,
+			RunID:   runID,
+		})
+		return
+	}
+
+	collector := &vault.Collector{}
+	observer := vault.MultiObserver(collector, vault.LogObserver{Operation: "rewriteValues"})
+
+	updates, err := vault.RewriteValues(r.Context(), client, opts, observer)
+
+	response := PasswordUpdateResponse{
+		Mode:    req.Mode,
+		RunID:   runID,
+		Updates: updates,
+	}
+
+	if req.IncludeEvents {
+		response.Events = collector.Events()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if err != nil {
+		response.Message = fmt.Sprintf("Erro ao processar a solicitação: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(response)
+		return
+	}
+
+	response.Success = true
+	switch {
+	case len(updates) == 0:
+		response.Message = "Nenhum valor correspondente encontrado"
+	case opts.Mode == vault.ListMode:
+		response.Message = fmt.Sprintf("Encontrados %d valores a reescrever (modo: apenas listagem)", len(updates))
+	default:
+		response.Message = fmt.Sprintf("Reescritos %d valores", len(updates))
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
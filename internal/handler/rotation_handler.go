@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"devops-go-vault-api/internal/rotation"
+	"devops-go-vault-api/internal/vault"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+type RotateCredentialRequest struct {
+	SGBD           string               `json:"sgbd"`
+	Host           string               `json:"host"`
+	Application    string               `json:"application"`
+	Mount          string               `json:"mount,omitempty"`
+	BasePath       string               `json:"base_path,omitempty"`
+	NewPassword    string               `json:"new_password,omitempty"`
+	PasswordPolicy vault.PasswordPolicy `json:"password_policy,omitempty"`
+
+	IncludeEvents bool `json:"include_events,omitempty"`
+}
+
+type RotateCredentialResponse struct {
+	Success bool             `json:"success"`
+	Message string           `json:"message,omitempty"`
+	Result  *rotation.Result `json:"result,omitempty"`
+	Events  []vault.Event    `json:"events,omitempty"`
+}
+
+func RotateCredentialHandler(w http.ResponseWriter, r *http.Request) {
+	var req RotateCredentialRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Erro ao decodificar a solicitação JSON", http.StatusBadRequest)
+		return
+	}
+
+	if req.SGBD == "" || req.Host == "" || req.Application == "" {
+		http.Error(w, "sgbd, host e application são obrigatórios", http.StatusBadRequest)
+		return
+	}
+
+	rotator, err := rotation.RotatorFor(req.SGBD)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	client, ok := vaultClient(w, r)
+	if !ok {
+		return
+	}
+
+	owner, ok := jobOwner(w, r)
+	if !ok {
+		return
+	}
+
+	collector := &vault.Collector{}
+	observer := vault.MultiObserver(collector, vault.LogObserver{Operation: "rotateCredential"})
+
+	result, err := rotation.Rotate(r.Context(), client, rotator, rotation.Request{
+		SGBD:        req.SGBD,
+		Host:        req.Host,
+		Application: req.Application,
+		Mount:       req.Mount,
+		BasePath:    strings.TrimSuffix(req.BasePath, "/"),
+		NewPassword: req.NewPassword,
+		Policy:      req.PasswordPolicy,
+		Owner:       owner,
+	}, observer)
+
+	response := RotateCredentialResponse{Result: result}
+	if req.IncludeEvents {
+		response.Events = collector.Events()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if err != nil {
+		response.Message = fmt.Sprintf("Erro na rotação: %v", err)
+		if errors.Is(err, rotation.ErrRolledBack) {
+			w.WriteHeader(http.StatusConflict)
+		} else if errors.Is(err, vault.ErrProtectedPath) {
+			w.WriteHeader(http.StatusForbidden)
+		} else {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		json.NewEncoder(w).Encode(response)
+		return
+	}
+
+	response.Success = true
+	response.Message = fmt.Sprintf("Senha de '%s' alterada no banco, em %s e em outras %d referências no Vault", result.Username, result.Path, len(result.Updates))
+	json.NewEncoder(w).Encode(response)
+}
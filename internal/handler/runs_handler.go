@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"devops-go-vault-api/internal/vault"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+type RollbackResponse struct {
+	Success bool                   `json:"success"`
+	Message string                 `json:"message"`
+	RunID   string                 `json:"run_id"`
+	State   vault.RollbackState    `json:"rollback_state"`
+	Results []vault.RollbackResult `json:"results"`
+}
+
+func GetRunHandler(w http.ResponseWriter, r *http.Request) {
+	owner, ok := jobOwner(w, r)
+	if !ok {
+		return
+	}
+
+	run, ok := vault.GetRunLog(mux.Vars(r)["id"], owner)
+	if !ok {
+		http.Error(w, "Execução não encontrada", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(run.Snapshot())
+}
+
+// RollbackRunHandler restaura os caminhos alterados pela execução; os que foram
+// alterados de novo depois dela são apenas informados.
+func RollbackRunHandler(w http.ResponseWriter, r *http.Request) {
+	owner, ok := jobOwner(w, r)
+	if !ok {
+		return
+	}
+
+	run, ok := vault.GetRunLog(mux.Vars(r)["id"], owner)
+	if !ok {
+		http.Error(w, "Execução não encontrada", http.StatusNotFound)
+		return
+	}
+
+	client, ok := vaultClient(w, r)
+	if !ok {
+		return
+	}
+
+	results, err := run.Rollback(r.Context(), client)
+	if errors.Is(err, vault.ErrRollbackInProgress) || errors.Is(err, vault.ErrAlreadyRolledBack) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	restored, skipped, failed := 0, 0, 0
+	for _, result := range results {
+		switch result.Status {
+		case vault.RollbackRestored:
+			restored++
+		case vault.RollbackModifiedSince:
+			skipped++
+		default:
+			failed++
+		}
+	}
+
+	response := RollbackResponse{
+		Success: skipped == 0 && failed == 0,
+		Message: fmt.Sprintf("%d caminhos restaurados, %d alterados desde a execução, %d falhas", restored, skipped, failed),
+		RunID:   run.ID,
+		State:   run.Snapshot().RollbackState,
+		Results: results,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if !response.Success {
+		w.WriteHeader(http.StatusMultiStatus)
+	}
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"devops-go-vault-api/config"
+	"devops-go-vault-api/internal/vault"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+type SearchRequest struct {
+	BasePath string `json:"base_path"`
+	vault.SearchCriteria
+
+	IncludeEvents bool `json:"include_events,omitempty"`
+}
+
+type SearchResponse struct {
+	Success bool                `json:"success"`
+	Message string              `json:"message,omitempty"`
+	Matches []vault.SearchMatch `json:"matches"`
+	Events  []vault.Event       `json:"events,omitempty"`
+}
+
+func SearchSecretsHandler(w http.ResponseWriter, r *http.Request) {
+	var req SearchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Erro ao decodificar a solicitação JSON", http.StatusBadRequest)
+		return
+	}
+
+	matcher, err := vault.NewMatcher(req.SearchCriteria)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if req.BasePath == "" {
+		req.BasePath = config.VaultKVMount
+	}
+	req.BasePath = strings.TrimSuffix(req.BasePath, "/")
+
+	client, ok := vaultClient(w, r)
+	if !ok {
+		return
+	}
+
+	collector := &vault.Collector{}
+	observer := vault.MultiObserver(collector, vault.LogObserver{Operation: "searchSecrets"})
+
+	matches, err := vault.SearchSecrets(r.Context(), client, req.BasePath, matcher, observer)
+
+	response := SearchResponse{
+		Matches: matches,
+	}
+
+	if req.IncludeEvents {
+		response.Events = collector.Events()
+	}
+
+	if err != nil {
+		response.Message = fmt.Sprintf("Erro ao processar a busca: %v", err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(response)
+		return
+	}
+
+	response.Success = true
+	response.Message = fmt.Sprintf("Encontradas %d ocorrências", len(matches))
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
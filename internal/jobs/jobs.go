@@ -0,0 +1,255 @@
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"devops-go-vault-api/internal/vault"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+const retention = 24 * time.Hour
+
+type Status string
+
+const (
+	StatusRunning   Status = "running"
+	StatusSucceeded Status = "succeeded"
+	StatusFailed    Status = "failed"
+	StatusCanceled  Status = "canceled"
+)
+
+type ProgressSnapshot struct {
+	Scanned int64 `json:"scanned"`
+	Matches int64 `json:"matches"`
+	Errors  int64 `json:"errors"`
+}
+
+// Job é a visão serializável de uma execução; Results só é preenchido ao final.
+type Job struct {
+	ID         string                       `json:"id"`
+	Kind       string                       `json:"kind"`
+	Status     Status                       `json:"status"`
+	CreatedAt  time.Time                    `json:"created_at"`
+	FinishedAt *time.Time                   `json:"finished_at,omitempty"`
+	Progress   ProgressSnapshot             `json:"progress"`
+	Error      string                       `json:"error,omitempty"`
+	Results    []vault.PasswordUpdateResult `json:"results,omitempty"`
+
+	// GeneratedPassword só aparece depois que ao menos um segredo foi gravado com
+	// sucesso, e uma única vez (na primeira consulta via Get; Peek nunca a traz).
+	GeneratedPassword string `json:"generated_password,omitempty"`
+}
+
+// RunFunc deve repassar observer às operações do pacote vault para que o progresso
+// do job seja atualizado.
+type RunFunc func(ctx context.Context, observer vault.Observer) ([]vault.PasswordUpdateResult, error)
+
+type job struct {
+	mu       sync.Mutex
+	info     Job
+	owner    string
+	password string
+	progress vault.Progress
+	cancel   context.CancelFunc
+}
+
+type Manager struct {
+	mu   sync.Mutex
+	jobs map[string]*job
+}
+
+var Default = NewManager()
+
+func NewManager() *Manager {
+	return &Manager{jobs: make(map[string]*job)}
+}
+
+func newID() (string, error) {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate job id: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// Start executa run em segundo plano, desvinculado da requisição HTTP que o criou.
+// Só quem tiver o mesmo owner consegue consultar ou cancelar o job depois.
+func (m *Manager) Start(kind, owner string, run RunFunc) (Job, error) {
+	return m.StartWithPassword(kind, owner, "", run)
+}
+
+// StartWithPassword é Start para jobs que gravam uma senha gerada no servidor: password
+// é exposta em GeneratedPassword apenas se algum resultado foi gravado sem erro.
+func (m *Manager) StartWithPassword(kind, owner, password string, run RunFunc) (Job, error) {
+	id, err := newID()
+	if err != nil {
+		return Job{}, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	j := &job{
+		info: Job{
+			ID:        id,
+			Kind:      kind,
+			Status:    StatusRunning,
+			CreatedAt: time.Now(),
+		},
+		owner:    owner,
+		password: password,
+		cancel:   cancel,
+	}
+
+	m.mu.Lock()
+	m.prune()
+	m.jobs[j.info.ID] = j
+	m.mu.Unlock()
+
+	go func() {
+		defer cancel()
+
+		observer := vault.MultiObserver(&j.progress, vault.LogObserver{Operation: kind})
+		results, err := run(ctx, observer)
+
+		j.mu.Lock()
+		defer j.mu.Unlock()
+
+		now := time.Now()
+		j.info.FinishedAt = &now
+		j.info.Results = results
+
+		for _, result := range results {
+			if result.Error == "" {
+				j.info.GeneratedPassword = j.password
+				break
+			}
+		}
+		j.password = ""
+
+		switch {
+		case ctx.Err() != nil:
+			j.info.Status = StatusCanceled
+		case err != nil:
+			j.info.Status = StatusFailed
+			j.info.Error = err.Error()
+		default:
+			j.info.Status = StatusSucceeded
+		}
+	}()
+
+	info := j.snapshot()
+	info.GeneratedPassword = ""
+	return info, nil
+}
+
+func (m *Manager) prune() {
+	cutoff := time.Now().Add(-retention)
+	for id, j := range m.jobs {
+		j.mu.Lock()
+		expired := j.info.FinishedAt != nil && j.info.FinishedAt.Before(cutoff)
+		j.mu.Unlock()
+
+		if expired {
+			delete(m.jobs, id)
+		}
+	}
+}
+
+func (j *job) snapshot() Job {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.snapshotLocked()
+}
+
+func (j *job) snapshotLocked() Job {
+	info := j.info
+	info.Progress = ProgressSnapshot{
+		Scanned: j.progress.Scanned.Load(),
+		Matches: j.progress.Matches.Load(),
+		Errors:  j.progress.Errors.Load(),
+	}
+	return info
+}
+
+// find trata jobs de outro owner como inexistentes
+func (m *Manager) find(id, owner string) (*job, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	j, ok := m.jobs[id]
+	if !ok || j.owner != owner {
+		return nil, false
+	}
+	return j, true
+}
+
+// Get entrega GeneratedPassword uma única vez; as consultas seguintes não a trazem.
+func (m *Manager) Get(id, owner string) (Job, bool) {
+	j, ok := m.find(id, owner)
+	if !ok {
+		return Job{}, false
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	info := j.snapshotLocked()
+	j.info.GeneratedPassword = ""
+	return info, true
+}
+
+// Peek é Get sem GeneratedPassword: não consome a senha, que continua disponível para
+// o próximo Get.
+func (m *Manager) Peek(id, owner string) (Job, bool) {
+	j, ok := m.find(id, owner)
+	if !ok {
+		return Job{}, false
+	}
+
+	info := j.snapshot()
+	info.GeneratedPassword = ""
+	return info, true
+}
+
+// List devolve os jobs de owner do mais recente para o mais antigo, sem os resultados.
+func (m *Manager) List(owner string) []Job {
+	m.mu.Lock()
+	all := make([]*job, 0, len(m.jobs))
+	for _, j := range m.jobs {
+		if j.owner == owner {
+			all = append(all, j)
+		}
+	}
+	m.mu.Unlock()
+
+	list := make([]Job, 0, len(all))
+	for _, j := range all {
+		info := j.snapshot()
+		info.Results = nil
+		info.GeneratedPassword = ""
+		list = append(list, info)
+	}
+
+	sort.Slice(list, func(i, k int) bool {
+		return list[i].CreatedAt.After(list[k].CreatedAt)
+	})
+
+	return list
+}
+
+func (m *Manager) Cancel(id, owner string) (Job, bool) {
+	j, ok := m.find(id, owner)
+	if !ok {
+		return Job{}, false
+	}
+
+	j.cancel()
+
+	info := j.snapshot()
+	info.GeneratedPassword = ""
+	return info, true
+}
@@ -0,0 +1,161 @@
+package jobs
+
+import (
+	"context"
+	"devops-go-vault-api/internal/vault"
+	"testing"
+	"time"
+)
+
+// blockingRun só termina quando o job é cancelado.
+func blockingRun(ctx context.Context, observer vault.Observer) ([]vault.PasswordUpdateResult, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func waitFinished(t *testing.T, m *Manager, id, owner string) Job {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if j, ok := m.Peek(id, owner); ok && j.Status != StatusRunning {
+			return j
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("job %s did not finish", id)
+	return Job{}
+}
+
+func TestJobsAreScopedToOwner(t *testing.T) {
+	m := NewManager()
+
+	job, err := m.Start("updatePassword", "owner-a", blockingRun)
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	t.Cleanup(func() { m.Cancel(job.ID, "owner-a") })
+
+	if _, ok := m.Get(job.ID, "owner-b"); ok {
+		t.Error("Get returned another owner's job")
+	}
+	if list := m.List("owner-b"); len(list) != 0 {
+		t.Errorf("List returned %d jobs of another owner", len(list))
+	}
+	if _, ok := m.Cancel(job.ID, "owner-b"); ok {
+		t.Error("Cancel accepted another owner's job")
+	}
+
+	got, ok := m.Get(job.ID, "owner-a")
+	if !ok || got.Status != StatusRunning {
+		t.Errorf("Get = %+v, %v; want the job still running", got, ok)
+	}
+	if list := m.List("owner-a"); len(list) != 1 || list[0].ID != job.ID {
+		t.Errorf("List = %+v, want only %s", list, job.ID)
+	}
+}
+
+func TestCancelMarksJobCanceled(t *testing.T) {
+	m := NewManager()
+
+	job, err := m.Start("updatePassword", "", blockingRun)
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+
+	if _, ok := m.Cancel(job.ID, ""); !ok {
+		t.Fatal("Cancel did not find the job")
+	}
+
+	got := waitFinished(t, m, job.ID, "")
+	if got.Status != StatusCanceled || got.FinishedAt == nil {
+		t.Errorf("job = %+v, want canceled with finished_at", got)
+	}
+}
+
+func TestJobStatusFromRun(t *testing.T) {
+	m := NewManager()
+
+	ok, _ := m.Start("updatePassword", "", func(ctx context.Context, observer vault.Observer) ([]vault.PasswordUpdateResult, error) {
+		return []vault.PasswordUpdateResult{{Path: "secret/data/app", Key: "DB_PASSWORD"}}, nil
+	})
+	failed, _ := m.Start("updatePassword", "", func(ctx context.Context, observer vault.Observer) ([]vault.PasswordUpdateResult, error) {
+		return nil, context.DeadlineExceeded
+	})
+
+	if got := waitFinished(t, m, ok.ID, ""); got.Status != StatusSucceeded || len(got.Results) != 1 {
+		t.Errorf("job = %+v, want succeeded with one result", got)
+	}
+	if got := waitFinished(t, m, failed.ID, ""); got.Status != StatusFailed || got.Error == "" {
+		t.Errorf("job = %+v, want failed with the error", got)
+	}
+}
+
+func TestPruneDropsOnlyExpiredJobs(t *testing.T) {
+	m := NewManager()
+
+	expired := time.Now().Add(-retention - time.Minute)
+	recent := time.Now().Add(-time.Minute)
+	m.jobs["expired"] = &job{info: Job{ID: "expired", Status: StatusSucceeded, FinishedAt: &expired}}
+	m.jobs["recent"] = &job{info: Job{ID: "recent", Status: StatusSucceeded, FinishedAt: &recent}}
+	m.jobs["running"] = &job{info: Job{ID: "running", Status: StatusRunning, CreatedAt: expired}}
+
+	m.prune()
+
+	if _, ok := m.jobs["expired"]; ok {
+		t.Error("job finished before the retention window was kept")
+	}
+	for _, id := range []string{"recent", "running"} {
+		if _, ok := m.jobs[id]; !ok {
+			t.Errorf("job %s was pruned", id)
+		}
+	}
+}
+
+func TestGeneratedPasswordIsDeliveredOnce(t *testing.T) {
+	m := NewManager()
+
+	job, err := m.StartWithPassword("updatePassword", "", "senha-gerada", func(ctx context.Context, observer vault.Observer) ([]vault.PasswordUpdateResult, error) {
+		return []vault.PasswordUpdateResult{{Path: "secret/data/app", Key: "DB_PASSWORD"}}, nil
+	})
+	if err != nil {
+		t.Fatalf("StartWithPassword: %v", err)
+	}
+	if job.GeneratedPassword != "" {
+		t.Error("Start returned the generated password")
+	}
+
+	// Peek, List e Cancel não consomem nem expõem a senha
+	if got := waitFinished(t, m, job.ID, ""); got.GeneratedPassword != "" {
+		t.Error("Peek returned the generated password")
+	}
+	if got, _ := m.Peek(job.ID, ""); got.GeneratedPassword != "" {
+		t.Error("Peek returned the generated password")
+	}
+	if list := m.List(""); len(list) != 1 || list[0].GeneratedPassword != "" {
+		t.Error("List returned the generated password")
+	}
+	if got, _ := m.Cancel(job.ID, ""); got.GeneratedPassword != "" {
+		t.Error("Cancel returned the generated password")
+	}
+
+	if got, _ := m.Get(job.ID, ""); got.GeneratedPassword != "senha-gerada" {
+		t.Errorf("first Get = %q, want the generated password", got.GeneratedPassword)
+	}
+	if got, _ := m.Get(job.ID, ""); got.GeneratedPassword != "" {
+		t.Error("second Get returned the generated password again")
+	}
+}
+
+func TestGeneratedPasswordWithheldWhenNothingWasWritten(t *testing.T) {
+	m := NewManager()
+
+	job, _ := m.StartWithPassword("updatePassword", "", "senha-gerada", func(ctx context.Context, observer vault.Observer) ([]vault.PasswordUpdateResult, error) {
+		return []vault.PasswordUpdateResult{{Path: "secret/data/app", Key: "DB_PASSWORD", Error: "permission denied"}}, nil
+	})
+
+	waitFinished(t, m, job.ID, "")
+	if got, _ := m.Get(job.ID, ""); got.GeneratedPassword != "" {
+		t.Error("Get returned a password that was never written")
+	}
+}
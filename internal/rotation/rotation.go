@@ -0,0 +1,196 @@
+package rotation
+
+import (
+	"context"
+	"devops-go-vault-api/config"
+	"devops-go-vault-api/internal/vault"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+)
+
+const dbTimeout = 30 * time.Second
+
+var defaultPorts = map[string]string{
+	"postgres":  "5432",
+	"sqlserver": "1433",
+	"oracle":    "1521",
+}
+
+type Credentials struct {
+	SGBD     string
+	Host     string
+	Port     string
+	Database string
+	Username string
+	Password string
+}
+
+// Request identifica o segredo em general/dba/<sgbd>/<host>/<application>, no formato
+// gravado por /jsonToVaultJson. BasePath é onde as demais referências à senha são
+// procuradas (padrão: Mount). Owner é o dono do RunLog da varredura (veja jobs).
+type Request struct {
+	SGBD        string
+	Host        string
+	Application string
+	Mount       string
+	BasePath    string
+	NewPassword string
+	Policy      vault.PasswordPolicy
+	Owner       string
+}
+
+type Result struct {
+	Path            string                       `json:"path"`
+	Username        string                       `json:"username,omitempty"`
+	PasswordSource  vault.PasswordSource         `json:"password_source,omitempty"`
+	PasswordChanged bool                         `json:"password_changed"`
+	LoginVerified   bool                         `json:"login_verified"`
+	VaultUpdated    bool                         `json:"vault_updated"`
+	RolledBack      bool                         `json:"rolled_back,omitempty"`
+	RunID           string                       `json:"run_id,omitempty"`
+	Updates         []vault.PasswordUpdateResult `json:"updates,omitempty"`
+}
+
+var ErrRolledBack = errors.New("rotation failed and the database password was restored")
+
+// Rotate troca a senha no banco, confirma o login com a nova senha, grava a nova senha
+// no segredo do DBA e por fim substitui as demais referências à senha antiga no Vault.
+// Se o login ou a gravação no Vault falharem, a senha antiga é restaurada no banco.
+func Rotate(ctx context.Context, client *api.Client, rotator Rotator, req Request, observer vault.Observer) (*Result, error) {
+	sgbd := strings.ToLower(req.SGBD)
+	if req.Mount == "" {
+		req.Mount = config.VaultKVMount
+	}
+	if req.BasePath == "" {
+		req.BasePath = req.Mount
+	}
+
+	path := vault.KVDataPath(req.Mount, fmt.Sprintf("general/dba/%s/%s/%s", sgbd, req.Host, req.Application))
+	result := &Result{Path: path}
+
+	// Checado antes de tocar no banco: a gravação no Vault seria recusada depois da troca
+	if kvPath, err := vault.ResolvePath(client, path); err == nil && vault.IsProtected(kvPath) {
+		return result, fmt.Errorf("%w: '%s' is in VAULT_PROTECTED_PATHS", vault.ErrProtectedPath, path)
+	}
+
+	creds, err := loadCredentials(client, path, sgbd, req.Host)
+	if err != nil {
+		return result, err
+	}
+	result.Username = creds.Username
+
+	newPassword := req.NewPassword
+	if newPassword == "" {
+		newPassword, result.PasswordSource, err = vault.GeneratePassword(ctx, client, req.Policy)
+		if err != nil {
+			return result, fmt.Errorf("failed to generate new password: %w", err)
+		}
+	}
+	if newPassword == creds.Password {
+		return result, fmt.Errorf("new password must differ from the current one")
+	}
+
+	newCreds := creds
+	newCreds.Password = newPassword
+
+	// Da troca no banco até a gravação no Vault nada pode ser interrompido pelo cliente:
+	// um cancelamento depois do ALTER USER deixaria a nova senha só no banco.
+	critical := context.WithoutCancel(ctx)
+
+	if err := withTimeout(critical, func(ctx context.Context) error {
+		return rotator.ChangePassword(ctx, creds, newPassword)
+	}); err != nil {
+		return result, err
+	}
+	result.PasswordChanged = true
+
+	if err := withTimeout(critical, func(ctx context.Context) error {
+		return rotator.VerifyLogin(ctx, newCreds)
+	}); err != nil {
+		return result, restore(critical, rotator, newCreds, creds.Password, result, err)
+	}
+	result.LoginVerified = true
+
+	passwordKey := strings.ToUpper(sgbd) + "_PASSWORD"
+	if err := vault.StoreInVaultWithContext(critical, client, path, map[string]string{passwordKey: newPassword}, vault.StoreOptions{Mode: vault.MergeWrite}); err != nil {
+		return result, restore(critical, rotator, newCreds, creds.Password, result, err)
+	}
+	result.VaultUpdated = true
+
+	// A partir daqui o banco e o segredo do DBA já usam a nova senha; falhas na varredura
+	// são informadas por referência e podem ser corrigidas com /updatePassword. As
+	// referências alteradas ficam no RunLog para /runs/{id}/rollback.
+	runLog, err := vault.NewRunLog("rotateCredential", req.BasePath, req.Owner)
+	if err != nil {
+		return result, fmt.Errorf("database and '%s' rotated, but updating other references failed: %w", path, err)
+	}
+	result.RunID = runLog.ID
+
+	result.Updates, err = vault.SearchAndReplaceMatching(ctx, client, req.BasePath, vault.ExactValue(creds.Password), newPassword, vault.EditMode, observer, vault.TreeOptions{RunLog: runLog})
+	if err != nil {
+		return result, fmt.Errorf("database and '%s' rotated, but updating other references failed: %w", path, err)
+	}
+
+	return result, nil
+}
+
+func loadCredentials(client *api.Client, path, sgbd, host string) (Credentials, error) {
+	view, err := vault.ReadSecret(client, path, 0)
+	if err != nil {
+		return Credentials{}, fmt.Errorf("failed to read '%s': %w", path, err)
+	}
+
+	prefix := strings.ToUpper(sgbd) + "_"
+	value := func(key string) string {
+		if v, ok := view.Data[prefix+key].(string); ok {
+			return v
+		}
+		return ""
+	}
+
+	creds := Credentials{
+		SGBD:     sgbd,
+		Host:     value("HOST"),
+		Port:     value("PORT"),
+		Database: value("DB"),
+		Username: value("USERNAME"),
+		Password: value("PASSWORD"),
+	}
+
+	if creds.Username == "" || creds.Password == "" {
+		return creds, fmt.Errorf("secret '%s' has no %sUSERNAME/%sPASSWORD", path, prefix, prefix)
+	}
+	if creds.Host == "" {
+		creds.Host = host
+	}
+	if creds.Port == "" {
+		creds.Port = defaultPorts[sgbd]
+	}
+
+	return creds, nil
+}
+
+// restore tenta voltar a senha antiga no banco usando a nova senha para autenticar.
+func restore(ctx context.Context, rotator Rotator, newCreds Credentials, oldPassword string, result *Result, cause error) error {
+	err := withTimeout(ctx, func(ctx context.Context) error {
+		return rotator.ChangePassword(ctx, newCreds, oldPassword)
+	})
+	if err != nil {
+		return fmt.Errorf("%v; restoring the old database password also failed, the new password is only in the database: %v", cause, err)
+	}
+
+	result.RolledBack = true
+	return fmt.Errorf("%w: %v", ErrRolledBack, cause)
+}
+
+// withTimeout limita cada operação no banco, já que um host inacessível pode
+// segurar a conexão indefinidamente.
+func withTimeout(ctx context.Context, fn func(ctx context.Context) error) error {
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+	return fn(ctx)
+}
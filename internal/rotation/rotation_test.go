@@ -0,0 +1,196 @@
+package rotation
+
+import (
+	"context"
+	"devops-go-vault-api/internal/vault"
+	"devops-go-vault-api/internal/vault/vaulttest"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+const (
+	dbaPath = "general/dba/postgres/db01/minha-app"
+	appPath = "minha-app/config"
+)
+
+// fakeRotator registra as trocas de senha e simula o banco só pela senha atual. Como
+// um driver de verdade, falha com ctx.Err() se o contexto já foi cancelado.
+type fakeRotator struct {
+	password  string
+	verifyErr error
+	calls     []string
+
+	// afterChange roda depois de cada troca de senha bem-sucedida
+	afterChange func()
+}
+
+func (f *fakeRotator) ChangePassword(ctx context.Context, creds Credentials, newPassword string) error {
+	f.calls = append(f.calls, "change:"+newPassword)
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if creds.Password != f.password {
+		return errors.New("login failed")
+	}
+	f.password = newPassword
+	if f.afterChange != nil {
+		f.afterChange()
+	}
+	return nil
+}
+
+func (f *fakeRotator) VerifyLogin(ctx context.Context, creds Credentials) error {
+	f.calls = append(f.calls, "verify:"+creds.Password)
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if f.verifyErr != nil {
+		return f.verifyErr
+	}
+	if creds.Password != f.password {
+		return errors.New("login failed")
+	}
+	return nil
+}
+
+func newRotationVault(t *testing.T) *vaulttest.Server {
+	srv := vaulttest.NewServer(t)
+	srv.Put(dbaPath, map[string]interface{}{
+		"POSTGRES_HOST":     "db01.exemplo.com",
+		"POSTGRES_USERNAME": "app_user",
+		"POSTGRES_PASSWORD": "senha-antiga",
+	})
+	srv.Put(appPath, map[string]interface{}{"DB_PASSWORD": "senha-antiga"})
+	return srv
+}
+
+func rotationRequest() Request {
+	return Request{
+		SGBD:        "postgres",
+		Host:        "db01",
+		Application: "minha-app",
+		Mount:       vaulttest.Mount,
+		NewPassword: "senha-nova-123456",
+	}
+}
+
+func TestRotateSuccess(t *testing.T) {
+	srv := newRotationVault(t)
+	rotator := &fakeRotator{password: "senha-antiga"}
+
+	result, err := Rotate(context.Background(), srv.Client(t), rotator, rotationRequest(), nil)
+	if err != nil {
+		t.Fatalf("Rotate: %v", err)
+	}
+
+	if rotator.password != "senha-nova-123456" {
+		t.Errorf("database password = %q, want the new password", rotator.password)
+	}
+	if !result.PasswordChanged || !result.LoginVerified || !result.VaultUpdated || result.RolledBack {
+		t.Errorf("unexpected result flags: %+v", result)
+	}
+	if got := srv.Data(dbaPath)["POSTGRES_PASSWORD"]; got != "senha-nova-123456" {
+		t.Errorf("DBA secret password = %v, want the new password", got)
+	}
+	if got := srv.Data(appPath)["DB_PASSWORD"]; got != "senha-nova-123456" {
+		t.Errorf("reference password = %v, want the new password", got)
+	}
+
+	run, ok := vault.GetRunLog(result.RunID, "")
+	if !ok {
+		t.Fatalf("run %q not registered", result.RunID)
+	}
+	if changes := run.Snapshot().Changes; len(changes) != 1 || changes[0].Path != vaulttest.Mount+"/data/"+appPath {
+		t.Errorf("run changes = %+v, want only %s", changes, appPath)
+	}
+}
+
+func TestRotateVerifyLoginFailureRestoresPassword(t *testing.T) {
+	srv := newRotationVault(t)
+	rotator := &fakeRotator{password: "senha-antiga", verifyErr: errors.New("login failed")}
+
+	result, err := Rotate(context.Background(), srv.Client(t), rotator, rotationRequest(), nil)
+	if !errors.Is(err, ErrRolledBack) {
+		t.Fatalf("err = %v, want ErrRolledBack", err)
+	}
+
+	want := []string{"change:senha-nova-123456", "verify:senha-nova-123456", "change:senha-antiga"}
+	if !reflect.DeepEqual(rotator.calls, want) {
+		t.Errorf("calls = %v, want %v", rotator.calls, want)
+	}
+	if rotator.password != "senha-antiga" {
+		t.Errorf("database password = %q, want it restored", rotator.password)
+	}
+	if !result.RolledBack || result.VaultUpdated {
+		t.Errorf("unexpected result flags: %+v", result)
+	}
+	if srv.Version(dbaPath) != 1 || srv.Version(appPath) != 1 {
+		t.Errorf("vault was modified: dba v%d, app v%d", srv.Version(dbaPath), srv.Version(appPath))
+	}
+}
+
+func TestRotateVaultWriteFailureRestoresPassword(t *testing.T) {
+	srv := newRotationVault(t)
+	srv.FailWrites(dbaPath)
+	rotator := &fakeRotator{password: "senha-antiga"}
+
+	result, err := Rotate(context.Background(), srv.Client(t), rotator, rotationRequest(), nil)
+	if !errors.Is(err, ErrRolledBack) {
+		t.Fatalf("err = %v, want ErrRolledBack", err)
+	}
+
+	if rotator.password != "senha-antiga" {
+		t.Errorf("database password = %q, want it restored", rotator.password)
+	}
+	if !result.LoginVerified || result.VaultUpdated || !result.RolledBack {
+		t.Errorf("unexpected result flags: %+v", result)
+	}
+	if got := srv.Data(appPath)["DB_PASSWORD"]; got != "senha-antiga" {
+		t.Errorf("reference password = %v, want it untouched", got)
+	}
+}
+
+func TestRotateIgnoresCancellationAfterPasswordChange(t *testing.T) {
+	srv := newRotationVault(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	rotator := &fakeRotator{password: "senha-antiga", afterChange: cancel}
+
+	result, err := Rotate(ctx, srv.Client(t), rotator, rotationRequest(), nil)
+	if errors.Is(err, ErrRolledBack) {
+		t.Fatalf("Rotate rolled back after the client went away: %v", err)
+	}
+
+	if rotator.password != "senha-nova-123456" {
+		t.Errorf("database password = %q, want the new password", rotator.password)
+	}
+	if !result.PasswordChanged || !result.LoginVerified || !result.VaultUpdated || result.RolledBack {
+		t.Errorf("unexpected result flags: %+v", result)
+	}
+	if got := srv.Data(dbaPath)["POSTGRES_PASSWORD"]; got != "senha-nova-123456" {
+		t.Errorf("DBA secret password = %v, want the new password", got)
+	}
+}
+
+func TestRotateRestoresPasswordAfterCancellation(t *testing.T) {
+	srv := newRotationVault(t)
+	srv.FailWrites(dbaPath)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	rotator := &fakeRotator{password: "senha-antiga", afterChange: cancel}
+
+	result, err := Rotate(ctx, srv.Client(t), rotator, rotationRequest(), nil)
+	if !errors.Is(err, ErrRolledBack) {
+		t.Fatalf("err = %v, want ErrRolledBack", err)
+	}
+
+	if rotator.password != "senha-antiga" {
+		t.Errorf("database password = %q, want it restored", rotator.password)
+	}
+	if !result.LoginVerified || result.VaultUpdated || !result.RolledBack {
+		t.Errorf("unexpected result flags: %+v", result)
+	}
+}
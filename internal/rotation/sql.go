@@ -0,0 +1,166 @@
+package rotation
+
+import (
+	"context"
+	"database/sql"
+	"devops-go-vault-api/config"
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/lib/pq"
+	_ "github.com/microsoft/go-mssqldb"
+	go_ora "github.com/sijms/go-ora/v2"
+)
+
+// Rotator troca a senha do próprio usuário no banco. É uma interface para que o
+// fluxo de rotação possa ser exercitado sem um banco real.
+type Rotator interface {
+	ChangePassword(ctx context.Context, creds Credentials, newPassword string) error
+	VerifyLogin(ctx context.Context, creds Credentials) error
+}
+
+type sqlRotator struct {
+	driver    string
+	dsn       func(creds Credentials) string
+	alterUser func(creds Credentials, newPassword string) (string, error)
+}
+
+var rotators = map[string]Rotator{
+	"postgres": sqlRotator{
+		driver:    "postgres",
+		dsn:       postgresDSN,
+		alterUser: postgresAlterUser,
+	},
+	"sqlserver": sqlRotator{
+		driver:    "sqlserver",
+		dsn:       sqlServerDSN,
+		alterUser: sqlServerAlterLogin,
+	},
+	"oracle": sqlRotator{
+		driver:    "oracle",
+		dsn:       oracleDSN,
+		alterUser: oracleAlterUser,
+	},
+}
+
+func RotatorFor(sgbd string) (Rotator, error) {
+	rotator, ok := rotators[strings.ToLower(sgbd)]
+	if !ok {
+		return nil, fmt.Errorf("unsupported sgbd '%s' (use postgres, sqlserver or oracle)", sgbd)
+	}
+	return rotator, nil
+}
+
+func (r sqlRotator) open(ctx context.Context, creds Credentials) (*sql.DB, error) {
+	db, err := sql.Open(r.driver, r.dsn(creds))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to login as '%s' on %s: %w", creds.Username, creds.Host, err)
+	}
+	return db, nil
+}
+
+// ChangePassword conecta com a senha atual e altera a senha do próprio usuário, sem
+// precisar de uma conta administrativa.
+func (r sqlRotator) ChangePassword(ctx context.Context, creds Credentials, newPassword string) error {
+	stmt, err := r.alterUser(creds, newPassword)
+	if err != nil {
+		return err
+	}
+
+	db, err := r.open(ctx, creds)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if _, err := db.ExecContext(ctx, stmt); err != nil {
+		return fmt.Errorf("failed to change password of '%s' on %s: %w", creds.Username, creds.Host, err)
+	}
+	return nil
+}
+
+func (r sqlRotator) VerifyLogin(ctx context.Context, creds Credentials) error {
+	db, err := r.open(ctx, creds)
+	if err != nil {
+		return err
+	}
+	return db.Close()
+}
+
+// O sslmode vem sempre de DB_POSTGRES_SSLMODE, sem depender do padrão do lib/pq.
+func postgresDSN(creds Credentials) string {
+	query := url.Values{}
+	if config.DBPostgresSSLMode != "" {
+		query.Set("sslmode", config.DBPostgresSSLMode)
+	}
+
+	dsn := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(creds.Username, creds.Password),
+		Host:     net.JoinHostPort(creds.Host, creds.Port),
+		Path:     "/" + creds.Database,
+		RawQuery: query.Encode(),
+	}
+	return dsn.String()
+}
+
+func postgresAlterUser(creds Credentials, newPassword string) (string, error) {
+	return fmt.Sprintf("ALTER USER %s WITH PASSWORD %s", pq.QuoteIdentifier(creds.Username), pq.QuoteLiteral(newPassword)), nil
+}
+
+func sqlServerDSN(creds Credentials) string {
+	query := url.Values{}
+	if creds.Database != "" {
+		query.Set("database", creds.Database)
+	}
+
+	dsn := url.URL{
+		Scheme:   "sqlserver",
+		User:     url.UserPassword(creds.Username, creds.Password),
+		Host:     net.JoinHostPort(creds.Host, creds.Port),
+		RawQuery: query.Encode(),
+	}
+	return dsn.String()
+}
+
+// No SQL Server a senha pertence ao login; sem permissão de ALTER ANY LOGIN é preciso
+// informar OLD_PASSWORD.
+func sqlServerAlterLogin(creds Credentials, newPassword string) (string, error) {
+	return fmt.Sprintf("ALTER LOGIN %s WITH PASSWORD = %s OLD_PASSWORD = %s",
+		"["+strings.ReplaceAll(creds.Username, "]", "]]")+"]",
+		sqlServerLiteral(newPassword),
+		sqlServerLiteral(creds.Password),
+	), nil
+}
+
+func sqlServerLiteral(value string) string {
+	return "N'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
+
+func oracleDSN(creds Credentials) string {
+	port, _ := strconv.Atoi(creds.Port)
+	return go_ora.BuildUrl(creds.Host, port, creds.Database, creds.Username, creds.Password, nil)
+}
+
+var oracleIdentifier = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_$#]*$`)
+
+// No Oracle a senha entre aspas duplas não pode conter aspas duplas, e o usuário fica sem
+// aspas para manter a comparação sem diferenciar maiúsculas.
+func oracleAlterUser(creds Credentials, newPassword string) (string, error) {
+	if !oracleIdentifier.MatchString(creds.Username) {
+		return "", fmt.Errorf("invalid oracle username '%s'", creds.Username)
+	}
+	if strings.Contains(newPassword, `"`) || strings.Contains(creds.Password, `"`) {
+		return "", fmt.Errorf("oracle passwords cannot contain double quotes")
+	}
+	return fmt.Sprintf(`ALTER USER %s IDENTIFIED BY "%s" REPLACE "%s"`, creds.Username, newPassword, creds.Password), nil
+}
@@ -0,0 +1,239 @@
+package vault
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/hashicorp/vault/api"
+)
+
+type AuditRule string
+
+const (
+	RuleMinLength    AuditRule = "min_length"
+	RuleCharClasses  AuditRule = "char_classes"
+	RuleKnownDefault AuditRule = "known_default"
+	RuleLowEntropy   AuditRule = "low_entropy"
+	RulePlaceholder  AuditRule = "placeholder"
+)
+
+const (
+	defaultAuditMinLength  = 12
+	defaultAuditMinClasses = 3
+	defaultAuditMinEntropy = 40
+)
+
+// knownDefaults são senhas padrão de fábrica ou comuns demais; comparadas sem
+// diferenciar maiúsculas.
+var knownDefaults = map[string]bool{
+	"123456": true, "12345678": true, "123456789": true, "admin": true, "admin123": true,
+	"administrator": true, "changeme": true, "changeit": true, "default": true, "letmein": true,
+	"manager": true, "oracle": true, "p@ssw0rd": true, "passw0rd": true, "password": true,
+	"password1": true, "password123": true, "postgres": true, "qwerty": true, "root": true,
+	"sa": true, "secret": true, "senha": true, "senha123": true, "sys": true, "system": true,
+	"test": true, "tiger": true, "welcome": true,
+}
+
+// defaultAuditKeyGlobs são usados quando KeyGlob não é informado.
+var defaultAuditKeyGlobs = []string{"*PASSWORD*", "*SECRET*", "*TOKEN*", "*KEY*"}
+
+var placeholderPattern = regexp.MustCompile(`\$\{[^}]*\}|\{\{[^}]*\}\}`)
+
+// referencePattern reconhece as referências {{caminho::CHAVE}} gravadas por /generate e
+// /jsonToVaultJson, que são resolvidas em outro lugar e não contam como placeholder.
+var referencePattern = regexp.MustCompile(`\{\{[^{}:]+::[^{}:]+\}\}`)
+
+// AuditOptions define as regras da auditoria; zeros usam os padrões (12 caracteres,
+// 3 classes, 40 bits de entropia). Placeholders são verificados em todas as chaves,
+// as demais regras apenas nas chaves aceitas por KeyGlob (padrão: *PASSWORD*, *SECRET*,
+// *TOKEN* e *KEY*). Valores com referências {{caminho::CHAVE}} não são avaliados.
+type AuditOptions struct {
+	BasePath string
+	Filter   PathFilter
+	KeyGlob  string
+
+	MinLength  int
+	MinClasses int
+	MinEntropy float64
+
+	// ExtraDefaults acrescenta valores proibidos à lista de senhas padrão.
+	ExtraDefaults []string
+}
+
+type AuditFinding struct {
+	Path       string      `json:"path"`
+	Key        string      `json:"key"`
+	Violations []AuditRule `json:"violations"`
+	Messages   []string    `json:"messages"`
+}
+
+type AuditReport struct {
+	BasePath string            `json:"base_path"`
+	Scanned  int               `json:"scanned"`
+	Checked  int               `json:"checked"`
+	Summary  map[AuditRule]int `json:"summary"`
+	Findings []AuditFinding    `json:"findings"`
+	Failures []ScanFailure     `json:"failures,omitempty"`
+}
+
+// AuditSecrets avalia cada valor abaixo de BasePath contra as regras e devolve as
+// violações por caminho e chave, sem nunca incluir os valores.
+func AuditSecrets(ctx context.Context, client *api.Client, opts AuditOptions, observer Observer) (*AuditReport, error) {
+	if opts.MinLength == 0 {
+		opts.MinLength = defaultAuditMinLength
+	}
+	if opts.MinClasses == 0 {
+		opts.MinClasses = defaultAuditMinClasses
+	}
+	if opts.MinEntropy == 0 {
+		opts.MinEntropy = defaultAuditMinEntropy
+	}
+	if opts.MinLength < 0 || opts.MinClasses < 0 || opts.MinClasses > 4 || opts.MinEntropy < 0 {
+		return nil, fmt.Errorf("invalid audit rules: min_length and min_entropy must not be negative and min_classes must be between 1 and 4")
+	}
+
+	globs := make([]Matcher, len(defaultAuditKeyGlobs))
+	for i, glob := range defaultAuditKeyGlobs {
+		globs[i] = KeyGlob(glob)
+	}
+	keys := AnyOf(globs...)
+	if opts.KeyGlob != "" {
+		matcher, err := NewMatcher(SearchCriteria{KeyGlob: opts.KeyGlob})
+		if err != nil {
+			return nil, err
+		}
+		keys = matcher
+	}
+
+	defaults := make(map[string]bool, len(knownDefaults)+len(opts.ExtraDefaults))
+	for value := range knownDefaults {
+		defaults[value] = true
+	}
+	for _, value := range opts.ExtraDefaults {
+		defaults[strings.ToLower(value)] = true
+	}
+
+	report := &AuditReport{
+		BasePath: opts.BasePath,
+		Summary:  make(map[AuditRule]int),
+		Findings: []AuditFinding{},
+	}
+	var mu sync.Mutex
+
+	failures, err := scanTree(ctx, client, opts.BasePath, opts.Filter, observer, func(_ context.Context, secret KVPath, data map[string]interface{}) {
+		var findings []AuditFinding
+		checked := 0
+
+		for key, value := range data {
+			strValue, ok := value.(string)
+			if !ok {
+				continue
+			}
+			checked++
+
+			finding := AuditFinding{Path: secret.DataPath(), Key: key}
+			add := func(rule AuditRule, message string) {
+				finding.Violations = append(finding.Violations, rule)
+				finding.Messages = append(finding.Messages, message)
+			}
+
+			hasReference := referencePattern.MatchString(strValue)
+			if placeholderPattern.MatchString(referencePattern.ReplaceAllString(strValue, "")) {
+				add(RulePlaceholder, "value contains an unrendered placeholder (${...} or {{...}})")
+			} else if !hasReference && keys.Match(key, strValue) {
+				if len(strValue) < opts.MinLength {
+					add(RuleMinLength, fmt.Sprintf("shorter than %d characters", opts.MinLength))
+				}
+				if classes := charClasses(strValue); classes < opts.MinClasses {
+					add(RuleCharClasses, fmt.Sprintf("uses %d of the %d required character classes", classes, opts.MinClasses))
+				}
+				if defaults[strings.ToLower(strValue)] {
+					add(RuleKnownDefault, "matches a known default or common password")
+				}
+				if entropyBits(strValue) < opts.MinEntropy {
+					add(RuleLowEntropy, fmt.Sprintf("estimated entropy below %.0f bits", opts.MinEntropy))
+				}
+			}
+
+			if len(finding.Violations) > 0 {
+				findings = append(findings, finding)
+				emit(observer, Event{Type: EventMatchFound, Path: finding.Path, Key: key, Message: "audit"})
+			}
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		report.Scanned++
+		report.Checked += checked
+		for _, finding := range findings {
+			for _, rule := range finding.Violations {
+				report.Summary[rule]++
+			}
+		}
+		report.Findings = append(report.Findings, findings...)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(report.Findings, func(i, j int) bool {
+		if report.Findings[i].Path != report.Findings[j].Path {
+			return report.Findings[i].Path < report.Findings[j].Path
+		}
+		return report.Findings[i].Key < report.Findings[j].Key
+	})
+	report.Failures = failures
+
+	return report, nil
+}
+
+func charClasses(value string) int {
+	var lower, upper, digit, symbol bool
+	for _, r := range value {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			symbol = true
+		}
+	}
+
+	count := 0
+	for _, present := range []bool{lower, upper, digit, symbol} {
+		if present {
+			count++
+		}
+	}
+	return count
+}
+
+// entropyBits estima a entropia do valor pela entropia de Shannon dos caracteres
+// multiplicada pelo comprimento; penaliza repetições como "aaaaaaaa".
+func entropyBits(value string) float64 {
+	runes := []rune(value)
+	if len(runes) == 0 {
+		return 0
+	}
+
+	counts := make(map[rune]int)
+	for _, r := range runes {
+		counts[r]++
+	}
+
+	var perChar float64
+	for _, count := range counts {
+		p := float64(count) / float64(len(runes))
+		perChar -= p * math.Log2(p)
+	}
+	return perChar * float64(len(runes))
+}
@@ -0,0 +1,153 @@
+package vault
+
+import (
+	"context"
+	"devops-go-vault-api/internal/vault/vaulttest"
+	"encoding/json"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// strongValue tem 16 caracteres distintos de 4 classes: 64 bits estimados.
+const strongValue = "Xk9#mQ2$vL7@pR4!"
+
+func TestAuditSecretsRules(t *testing.T) {
+	tests := []struct {
+		name  string
+		opts  AuditOptions
+		key   string
+		value string
+		want  []AuditRule
+	}{
+		{"strong", AuditOptions{}, "DB_PASSWORD", strongValue, nil},
+		{"min length", AuditOptions{MinLength: 20}, "DB_PASSWORD", strongValue, []AuditRule{RuleMinLength}},
+		{"char classes", AuditOptions{}, "DB_PASSWORD", "abcdefghijklmnop", []AuditRule{RuleCharClasses}},
+		{"extra default ignores case", AuditOptions{ExtraDefaults: []string{strings.ToLower(strongValue)}}, "DB_PASSWORD", strongValue, []AuditRule{RuleKnownDefault}},
+		{"low entropy", AuditOptions{}, "DB_PASSWORD", "Aa1!Aa1!Aa1!Aa1!", []AuditRule{RuleLowEntropy}},
+		{"known default breaks every rule", AuditOptions{}, "API_TOKEN", "Password", []AuditRule{RuleMinLength, RuleCharClasses, RuleKnownDefault, RuleLowEntropy}},
+		{"key outside default globs", AuditOptions{}, "DB_USER", "password", nil},
+		{"custom key glob", AuditOptions{KeyGlob: "*USER*"}, "DB_USER", "abcdefghijklmnop", []AuditRule{RuleCharClasses}},
+		{"custom key glob replaces defaults", AuditOptions{KeyGlob: "*USER*"}, "DB_PASSWORD", "password", nil},
+		// Placeholders são verificados em qualquer chave
+		{"placeholder", AuditOptions{}, "DB_HOST", "${DB_HOST}", []AuditRule{RulePlaceholder}},
+		{"template placeholder", AuditOptions{}, "DB_PASSWORD", "{{ senha }}", []AuditRule{RulePlaceholder}},
+		{"reference", AuditOptions{}, "DB_PASSWORD", "{{general/dba/postgres/db01/app::POSTGRES_PASSWORD}}", nil},
+		{"reference with placeholder", AuditOptions{}, "DB_URL", "postgres://{{general/dba/app::USER}}:${SENHA}@db01", []AuditRule{RulePlaceholder}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := vaulttest.NewServer(t)
+			srv.Put("app/config", map[string]interface{}{tt.key: tt.value})
+
+			tt.opts.BasePath = vaulttest.Mount
+			report, err := AuditSecrets(context.Background(), srv.Client(t), tt.opts, nil)
+			if err != nil {
+				t.Fatalf("AuditSecrets: %v", err)
+			}
+
+			var got []AuditRule
+			if len(report.Findings) > 0 {
+				got = report.Findings[0].Violations
+			}
+			if len(report.Findings) > 1 || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("findings = %+v, want violations %v", report.Findings, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuditSecretsReport(t *testing.T) {
+	srv := vaulttest.NewServer(t)
+	srv.Put("b/config", map[string]interface{}{"DB_PASSWORD": "senha123", "DB_PORT": "5432", "API_TOKEN": "${TOKEN}"})
+	srv.Put("a/config", map[string]interface{}{"DB_PASSWORD": strongValue})
+	srv.Put("c/config", map[string]interface{}{"REPLICAS": 3})
+
+	report, err := AuditSecrets(context.Background(), srv.Client(t), AuditOptions{BasePath: vaulttest.Mount}, nil)
+	if err != nil {
+		t.Fatalf("AuditSecrets: %v", err)
+	}
+
+	// Valores que não são string não são avaliados
+	if report.Scanned != 3 || report.Checked != 4 {
+		t.Errorf("scanned = %d, checked = %d, want 3 and 4", report.Scanned, report.Checked)
+	}
+
+	var keys []string
+	for _, finding := range report.Findings {
+		keys = append(keys, finding.Path+"#"+finding.Key)
+	}
+	wantKeys := []string{vaulttest.Mount + "/data/b/config#API_TOKEN", vaulttest.Mount + "/data/b/config#DB_PASSWORD"}
+	if !reflect.DeepEqual(keys, wantKeys) {
+		t.Errorf("findings = %v, want %v", keys, wantKeys)
+	}
+
+	wantSummary := map[AuditRule]int{RulePlaceholder: 1, RuleMinLength: 1, RuleCharClasses: 1, RuleKnownDefault: 1, RuleLowEntropy: 1}
+	if !reflect.DeepEqual(report.Summary, wantSummary) {
+		t.Errorf("summary = %v, want %v", report.Summary, wantSummary)
+	}
+
+	body, err := json.Marshal(report)
+	if err != nil {
+		t.Fatalf("json.Marshal: %v", err)
+	}
+	for _, value := range []string{"senha123", "${TOKEN}", strongValue} {
+		if strings.Contains(string(body), value) {
+			t.Errorf("report contains the value %q: %s", value, body)
+		}
+	}
+}
+
+func TestAuditSecretsRejectsInvalidRules(t *testing.T) {
+	srv := vaulttest.NewServer(t)
+
+	for _, opts := range []AuditOptions{{MinLength: -1}, {MinClasses: 5}, {MinEntropy: -1}, {KeyGlob: "["}} {
+		opts.BasePath = vaulttest.Mount
+		if _, err := AuditSecrets(context.Background(), srv.Client(t), opts, nil); err == nil {
+			t.Errorf("AuditSecrets accepted %+v", opts)
+		}
+	}
+}
+
+func TestCharClasses(t *testing.T) {
+	tests := []struct {
+		value string
+		want  int
+	}{
+		{"", 0},
+		{"abc", 1},
+		{"aBc", 2},
+		{"aB1", 3},
+		{"aB1!", 4},
+		{"ação", 1},
+		{"Senha 1", 4},
+	}
+
+	for _, tt := range tests {
+		if got := charClasses(tt.value); got != tt.want {
+			t.Errorf("charClasses(%q) = %d, want %d", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestEntropyBits(t *testing.T) {
+	tests := []struct {
+		value string
+		want  float64
+	}{
+		{"", 0},
+		{"aaaaaaaa", 0},
+		{"ab", 2},
+		{"aabb", 4},
+		{"abcd", 8},
+		{"ção!", 8},
+	}
+
+	for _, tt := range tests {
+		if got := entropyBits(tt.value); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("entropyBits(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
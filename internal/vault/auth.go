@@ -0,0 +1,229 @@
+package vault
+
+import (
+	"context"
+	"devops-go-vault-api/config"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+)
+
+const reloginRetryInterval = 10 * time.Second
+
+var (
+	clientMu     sync.Mutex
+	sharedClient *api.Client
+)
+
+type appRoleAuth struct {
+	mount    string
+	roleID   string
+	secretID string
+}
+
+func (a *appRoleAuth) Login(ctx context.Context, client *api.Client) (*api.Secret, error) {
+	return client.Logical().WriteWithContext(ctx, fmt.Sprintf("auth/%s/login", a.mount), map[string]interface{}{
+		"role_id":   a.roleID,
+		"secret_id": a.secretID,
+	})
+}
+
+type kubernetesAuth struct {
+	mount   string
+	role    string
+	jwtPath string
+}
+
+func (k *kubernetesAuth) Login(ctx context.Context, client *api.Client) (*api.Secret, error) {
+	// O token da service account é relido a cada login porque o kubelet o rotaciona
+	jwt, err := os.ReadFile(k.jwtPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read service account token '%s': %v", k.jwtPath, err)
+	}
+
+	return client.Logical().WriteWithContext(ctx, fmt.Sprintf("auth/%s/login", k.mount), map[string]interface{}{
+		"role": k.role,
+		"jwt":  strings.TrimSpace(string(jwt)),
+	})
+}
+
+type userpassAuth struct {
+	mount    string
+	username string
+	password string
+}
+
+func (u *userpassAuth) Login(ctx context.Context, client *api.Client) (*api.Secret, error) {
+	return client.Logical().WriteWithContext(ctx, fmt.Sprintf("auth/%s/login/%s", u.mount, u.username), map[string]interface{}{
+		"password": u.password,
+	})
+}
+
+func newAuthMethod() (api.AuthMethod, error) {
+	mount := strings.Trim(config.VaultAuthMount, "/")
+
+	switch config.VaultAuthMethod {
+	case "", "token":
+		return nil, nil
+	case "approle":
+		return &appRoleAuth{mount: mount, roleID: config.VaultRoleID, secretID: config.VaultSecretID}, nil
+	case "kubernetes":
+		return &kubernetesAuth{mount: mount, role: config.VaultK8sRole, jwtPath: config.VaultK8sJWTPath}, nil
+	case "userpass":
+		return &userpassAuth{mount: mount, username: config.VaultUsername, password: config.VaultPassword}, nil
+	default:
+		return nil, fmt.Errorf("unsupported auth method '%s'", config.VaultAuthMethod)
+	}
+}
+
+func GetClient() (*api.Client, error) {
+	clientMu.Lock()
+	defer clientMu.Unlock()
+
+	if sharedClient != nil {
+		return sharedClient, nil
+	}
+
+	conf := api.DefaultConfig()
+	conf.Address = config.VaultAddress
+
+	client, err := api.NewClient(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	method, err := newAuthMethod()
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := login(context.Background(), client, method)
+	if err != nil {
+		return nil, err
+	}
+
+	go manageTokenLifecycle(client, method, secret)
+
+	sharedClient = client
+	return sharedClient, nil
+}
+
+func ClientWithToken(token string) (*api.Client, error) {
+	base, err := GetClient()
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := base.Clone()
+	if err != nil {
+		return nil, err
+	}
+
+	client.SetToken(token)
+	return client, nil
+}
+
+func login(ctx context.Context, client *api.Client, method api.AuthMethod) (*api.Secret, error) {
+	if method == nil {
+		client.SetToken(config.VaultToken)
+		return lookupStaticToken(ctx, client)
+	}
+
+	secret, err := client.Auth().Login(ctx, method)
+	if err != nil {
+		return nil, fmt.Errorf("failed to login with auth method '%s': %v", config.VaultAuthMethod, err)
+	}
+
+	return secret, nil
+}
+
+// Tokens estáticos não têm resposta de login; montamos uma a partir do lookup-self
+// para que o LifetimeWatcher consiga renová-los quando possível.
+func lookupStaticToken(ctx context.Context, client *api.Client) (*api.Secret, error) {
+	self, err := client.Auth().Token().LookupSelfWithContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lookup VAULT_TOKEN: %v", err)
+	}
+
+	renewable, _ := self.TokenIsRenewable()
+	ttl, _ := self.TokenTTL()
+
+	return &api.Secret{
+		Auth: &api.SecretAuth{
+			ClientToken:   config.VaultToken,
+			Renewable:     renewable,
+			LeaseDuration: int(ttl.Seconds()),
+		},
+	}, nil
+}
+
+// TokenAccessor devolve o accessor do token de client, que identifica o token sem expô-lo.
+func TokenAccessor(ctx context.Context, client *api.Client) (string, error) {
+	self, err := client.Auth().Token().LookupSelfWithContext(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to lookup caller token: %w", err)
+	}
+
+	accessor, err := self.TokenAccessor()
+	if err != nil || accessor == "" {
+		return "", fmt.Errorf("caller token has no accessor")
+	}
+	return accessor, nil
+}
+
+func manageTokenLifecycle(client *api.Client, method api.AuthMethod, secret *api.Secret) {
+	for {
+		if secret == nil || secret.Auth == nil || secret.Auth.LeaseDuration <= 0 {
+			return
+		}
+
+		watchToken(client, secret)
+
+		if method == nil {
+			log.Printf("VAULT_TOKEN expirou e não pode ser renovado; reinicie o serviço com um novo token")
+			return
+		}
+
+		for {
+			var err error
+			secret, err = login(context.Background(), client, method)
+			if err == nil {
+				log.Printf("Novo login no Vault realizado com o método %s", config.VaultAuthMethod)
+				break
+			}
+
+			log.Printf("Erro ao refazer login no Vault: %v", err)
+			time.Sleep(reloginRetryInterval)
+		}
+	}
+}
+
+func watchToken(client *api.Client, secret *api.Secret) {
+	watcher, err := client.NewLifetimeWatcher(&api.LifetimeWatcherInput{Secret: secret})
+	if err != nil {
+		log.Printf("Erro ao iniciar renovação do token do Vault: %v", err)
+		return
+	}
+
+	go watcher.Start()
+	defer watcher.Stop()
+
+	for {
+		select {
+		case err := <-watcher.DoneCh():
+			if err != nil {
+				log.Printf("Renovação do token do Vault encerrada: %v", err)
+			}
+			return
+		case renewal := <-watcher.RenewCh():
+			if renewal.Secret != nil && renewal.Secret.Auth != nil {
+				log.Printf("Token do Vault renovado (ttl: %ds)", renewal.Secret.Auth.LeaseDuration)
+			}
+		}
+	}
+}
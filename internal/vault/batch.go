@@ -0,0 +1,207 @@
+package vault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/hashicorp/vault/api"
+)
+
+type BatchStatus string
+
+const (
+	BatchPending        BatchStatus = "pending"
+	BatchInvalid        BatchStatus = "invalid"
+	BatchWritten        BatchStatus = "written"
+	BatchFailed         BatchStatus = "failed"
+	BatchNotAttempted   BatchStatus = "not_attempted"
+	BatchRolledBack     BatchStatus = "rolled_back"
+	BatchRollbackFailed BatchStatus = "rollback_failed"
+)
+
+var ErrBatchInvalid = errors.New("batch validation failed")
+var ErrBatchFailed = errors.New("batch write failed")
+
+type BatchItem struct {
+	Path string
+	Data map[string]string
+	Mode string
+	CAS  *int
+}
+
+type BatchResult struct {
+	Path            string      `json:"path"`
+	Status          BatchStatus `json:"status"`
+	PreviousVersion int         `json:"previous_version,omitempty"`
+	Version         int         `json:"version,omitempty"`
+	Error           string      `json:"error,omitempty"`
+}
+
+type batchEntry struct {
+	kvPath          KVPath
+	data            map[string]interface{}
+	opts            StoreOptions
+	existed         bool
+	previousData    map[string]interface{}
+	previousVersion int
+}
+
+// StoreBatch valida todos os itens, guarda o estado atual de cada caminho e só então
+// grava. Se alguma escrita falhar (inclusive por cancelamento de ctx), os caminhos já
+// gravados voltam ao estado anterior; o rollback não é interrompido pelo cancelamento.
+func StoreBatch(ctx context.Context, client *api.Client, items []BatchItem) ([]BatchResult, error) {
+	ctx = WithRateLimit(ctx)
+
+	results := make([]BatchResult, len(items))
+	entries := make([]batchEntry, len(items))
+	seen := make(map[string]int)
+	invalid, protected := false, false
+
+	for i, item := range items {
+		results[i] = BatchResult{Path: item.Path, Status: BatchPending}
+
+		entry, err := validateBatchItem(client, item)
+		if err == nil {
+			if first, dup := seen[entry.kvPath.String()]; dup {
+				err = fmt.Errorf("path duplicated in batch (item %d)", first)
+			} else {
+				seen[entry.kvPath.String()] = i
+			}
+		}
+
+		if err != nil {
+			results[i].Status = BatchInvalid
+			results[i].Error = err.Error()
+			invalid = true
+			protected = protected || errors.Is(err, ErrProtectedPath)
+			continue
+		}
+
+		entries[i] = entry
+	}
+
+	if invalid {
+		markPending(results, BatchNotAttempted)
+		if protected {
+			return results, fmt.Errorf("%w: %w", ErrBatchInvalid, ErrProtectedPath)
+		}
+		return results, ErrBatchInvalid
+	}
+
+	for i := range entries {
+		data, version, err := readSecretDataWithContext(ctx, client, entries[i].kvPath)
+		if err != nil {
+			results[i].Status = BatchFailed
+			results[i].Error = fmt.Sprintf("failed to snapshot current version: %v", err)
+			markPending(results, BatchNotAttempted)
+			return results, ErrBatchFailed
+		}
+
+		entries[i].existed = data != nil
+		entries[i].previousData = data
+		entries[i].previousVersion = version
+		results[i].PreviousVersion = version
+	}
+
+	for i := range entries {
+		version, err := writeSecretDataWithContext(ctx, client, entries[i].kvPath, entries[i].data, entries[i].opts)
+		if err != nil {
+			results[i].Status = BatchFailed
+			results[i].Error = err.Error()
+			markPending(results, BatchNotAttempted)
+			rollbackBatch(context.WithoutCancel(ctx), client, entries[:i], results[:i])
+			return results, fmt.Errorf("%w: %w", ErrBatchFailed, err)
+		}
+
+		results[i].Status = BatchWritten
+		results[i].Version = version
+	}
+
+	return results, nil
+}
+
+func validateBatchItem(client *api.Client, item BatchItem) (batchEntry, error) {
+	if item.Path == "" || len(item.Data) == 0 {
+		return batchEntry{}, fmt.Errorf("path and data are required")
+	}
+
+	mode, err := ParseWriteMode(item.Mode)
+	if err != nil {
+		return batchEntry{}, err
+	}
+
+	kvPath, err := ResolvePath(client, item.Path)
+	if err != nil {
+		return batchEntry{}, err
+	}
+
+	if kvPath.Secret == "" {
+		return batchEntry{}, fmt.Errorf("path '%s' points to a mount, not a secret", item.Path)
+	}
+
+	if err := checkProtected(kvPath); err != nil {
+		return batchEntry{}, err
+	}
+
+	if item.CAS != nil && kvPath.Mount.Version != 2 {
+		return batchEntry{}, fmt.Errorf("mount '%s' is KV v1 and does not support check-and-set", kvPath.Mount.Path)
+	}
+
+	data := make(map[string]interface{})
+	for key, value := range item.Data {
+		data[key] = value
+	}
+
+	return batchEntry{
+		kvPath: kvPath,
+		data:   data,
+		opts:   StoreOptions{Mode: mode, CAS: item.CAS},
+	}, nil
+}
+
+func markPending(results []BatchResult, status BatchStatus) {
+	for i := range results {
+		if results[i].Status == BatchPending {
+			results[i].Status = status
+		}
+	}
+}
+
+func rollbackBatch(ctx context.Context, client *api.Client, entries []batchEntry, results []BatchResult) {
+	for i := len(entries) - 1; i >= 0; i-- {
+		if err := restoreEntry(ctx, client, entries[i], results[i].Version); err != nil {
+			results[i].Status = BatchRollbackFailed
+			results[i].Error = err.Error()
+			continue
+		}
+		results[i].Status = BatchRolledBack
+	}
+}
+
+func restoreEntry(ctx context.Context, client *api.Client, entry batchEntry, writtenVersion int) error {
+	if err := waitLimit(ctx); err != nil {
+		return err
+	}
+
+	if entry.kvPath.Mount.Version != 2 {
+		kv := client.KVv1(entry.kvPath.Mount.Path)
+		if !entry.existed {
+			return kv.Delete(ctx, entry.kvPath.Secret)
+		}
+		return kv.Put(ctx, entry.kvPath.Secret, entry.previousData)
+	}
+
+	kv := client.KVv2(entry.kvPath.Mount.Path)
+
+	switch {
+	case entry.existed:
+		_, err := kv.Rollback(ctx, entry.kvPath.Secret, entry.previousVersion)
+		return err
+	case entry.previousVersion == 0:
+		return kv.DeleteMetadata(ctx, entry.kvPath.Secret)
+	default:
+		// Havia metadados mas a versão atual estava deletada: basta deletar a versão nova
+		return kv.DeleteVersions(ctx, entry.kvPath.Secret, []int{writtenVersion})
+	}
+}
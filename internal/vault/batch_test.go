@@ -0,0 +1,102 @@
+package vault
+
+import (
+	"context"
+	"devops-go-vault-api/internal/vault/vaulttest"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestStoreBatchRollsBackOnWriteFailure(t *testing.T) {
+	srv := vaulttest.NewServer(t)
+	srv.Put("app/a", map[string]interface{}{"KEY": "antigo"})
+	srv.FailWrites("app/c")
+
+	results, err := StoreBatch(context.Background(), srv.Client(t), []BatchItem{
+		{Path: vaulttest.Mount + "/data/app/a", Data: map[string]string{"KEY": "novo"}},
+		{Path: vaulttest.Mount + "/data/app/b", Data: map[string]string{"KEY": "novo"}},
+		{Path: vaulttest.Mount + "/data/app/c", Data: map[string]string{"KEY": "novo"}},
+		{Path: vaulttest.Mount + "/data/app/d", Data: map[string]string{"KEY": "novo"}},
+	})
+	if !errors.Is(err, ErrBatchFailed) {
+		t.Fatalf("err = %v, want ErrBatchFailed", err)
+	}
+
+	var statuses []BatchStatus
+	for _, result := range results {
+		statuses = append(statuses, result.Status)
+	}
+	want := []BatchStatus{BatchRolledBack, BatchRolledBack, BatchFailed, BatchNotAttempted}
+	if !reflect.DeepEqual(statuses, want) {
+		t.Errorf("statuses = %v, want %v (results %+v)", statuses, want, results)
+	}
+
+	// a volta ao conteúdo anterior numa nova versão; b, que não existia, é removido
+	if got := srv.Data("app/a")["KEY"]; got != "antigo" {
+		t.Errorf("app/a KEY = %v, want it rolled back", got)
+	}
+	if srv.Version("app/b") != 0 {
+		t.Errorf("app/b still has %d versions, want it deleted", srv.Version("app/b"))
+	}
+	if srv.Version("app/d") != 0 {
+		t.Errorf("app/d was written after the failure")
+	}
+}
+
+func TestStoreBatchSuccess(t *testing.T) {
+	srv := vaulttest.NewServer(t)
+	srv.Put("app/a", map[string]interface{}{"KEY": "antigo", "OTHER": "x"})
+
+	results, err := StoreBatch(context.Background(), srv.Client(t), []BatchItem{
+		{Path: vaulttest.Mount + "/data/app/a", Data: map[string]string{"KEY": "novo"}, Mode: "merge"},
+		{Path: vaulttest.Mount + "/data/app/b", Data: map[string]string{"KEY": "novo"}},
+	})
+	if err != nil {
+		t.Fatalf("StoreBatch: %v", err)
+	}
+
+	if results[0].Status != BatchWritten || results[0].PreviousVersion != 1 || results[0].Version != 2 {
+		t.Errorf("result a = %+v", results[0])
+	}
+	if got := srv.Data("app/a"); got["KEY"] != "novo" || got["OTHER"] != "x" {
+		t.Errorf("app/a = %v, want KEY merged", got)
+	}
+	if got := srv.Data("app/b")["KEY"]; got != "novo" {
+		t.Errorf("app/b KEY = %v", got)
+	}
+}
+
+func TestStoreBatchValidation(t *testing.T) {
+	srv := vaulttest.NewServer(t)
+	withProtectedPaths(t, vaulttest.Mount+"/break-glass")
+
+	tests := []struct {
+		name          string
+		items         []BatchItem
+		wantProtected bool
+	}{
+		{"missing data", []BatchItem{{Path: vaulttest.Mount + "/app/a"}}, false},
+		{"invalid mode", []BatchItem{{Path: vaulttest.Mount + "/app/a", Data: map[string]string{"K": "v"}, Mode: "append"}}, false},
+		{"duplicated path", []BatchItem{
+			{Path: vaulttest.Mount + "/app/a", Data: map[string]string{"K": "v"}},
+			{Path: vaulttest.Mount + "/data/app/a", Data: map[string]string{"K": "v"}},
+		}, false},
+		{"protected path", []BatchItem{{Path: vaulttest.Mount + "/break-glass/root", Data: map[string]string{"K": "v"}}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := StoreBatch(context.Background(), srv.Client(t), tt.items)
+			if !errors.Is(err, ErrBatchInvalid) {
+				t.Fatalf("err = %v, want ErrBatchInvalid", err)
+			}
+			if errors.Is(err, ErrProtectedPath) != tt.wantProtected {
+				t.Errorf("err = %v, want protected %v", err, tt.wantProtected)
+			}
+			if srv.Version("app/a") != 0 || srv.Version("break-glass/root") != 0 {
+				t.Error("an invalid batch wrote to vault")
+			}
+		})
+	}
+}
@@ -0,0 +1,319 @@
+package vault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+)
+
+const bundleVersion = 1
+
+// BundleEntry segue o formato {path, data} aceito por /sendVault, acrescido dos
+// custom_metadata do KV v2. Path é o caminho lógico, sem data/.
+type BundleEntry struct {
+	Path           string                 `json:"path" yaml:"path"`
+	Data           map[string]interface{} `json:"data" yaml:"data"`
+	CustomMetadata map[string]interface{} `json:"custom_metadata,omitempty" yaml:"custom_metadata,omitempty"`
+}
+
+type Bundle struct {
+	Version   int           `json:"version" yaml:"version"`
+	Source    string        `json:"source" yaml:"source"`
+	CreatedAt time.Time     `json:"created_at" yaml:"created_at"`
+	Entries   []BundleEntry `json:"entries" yaml:"entries"`
+
+	// Failures lista o que ficou de fora de uma exportação parcial
+	Failures []ScanFailure `json:"failures,omitempty" yaml:"failures,omitempty"`
+}
+
+// ErrPartialExport indica que algum diretório ou segredo não pôde ser lido.
+var ErrPartialExport = errors.New("export is incomplete")
+
+// ExportBundle lê todos os segredos abaixo de basePath, com seus custom_metadata. Se
+// algum diretório, segredo ou metadado não puder ser lido, a exportação falha com
+// ErrPartialExport e as falhas; com allowPartial, o bundle parcial é devolvido com
+// as falhas em Failures.
+func ExportBundle(ctx context.Context, client *api.Client, basePath string, filter PathFilter, allowPartial bool, observer Observer) (*Bundle, []ScanFailure, error) {
+	bundle := &Bundle{
+		Version:   bundleVersion,
+		Source:    basePath,
+		CreatedAt: time.Now().UTC(),
+		Entries:   []BundleEntry{},
+	}
+	var mu sync.Mutex
+	var metadataFailures []ScanFailure
+
+	failures, err := scanTree(ctx, client, basePath, filter, observer, func(ctx context.Context, secret KVPath, data map[string]interface{}) {
+		entry := BundleEntry{Path: secret.String(), Data: data}
+
+		if secret.Mount.Version == 2 {
+			metadata, err := getMetadata(ctx, client, secret)
+			if err != nil {
+				emit(observer, Event{Type: EventReadFailed, Path: secret.MetadataPath(), Error: err.Error()})
+				mu.Lock()
+				metadataFailures = append(metadataFailures, ScanFailure{Path: secret.MetadataPath(), Error: err.Error()})
+				mu.Unlock()
+				return
+			}
+			if len(metadata.CustomMetadata) > 0 {
+				entry.CustomMetadata = metadata.CustomMetadata
+			}
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		bundle.Entries = append(bundle.Entries, entry)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	failures = append(failures, metadataFailures...)
+	sort.Slice(failures, func(i, j int) bool {
+		return failures[i].Path < failures[j].Path
+	})
+
+	if len(failures) > 0 && !allowPartial {
+		return nil, failures, fmt.Errorf("%w: %d paths could not be read", ErrPartialExport, len(failures))
+	}
+
+	sort.Slice(bundle.Entries, func(i, j int) bool {
+		return bundle.Entries[i].Path < bundle.Entries[j].Path
+	})
+	if len(failures) > 0 {
+		bundle.Failures = failures
+	}
+
+	return bundle, failures, nil
+}
+
+func getMetadata(ctx context.Context, client *api.Client, secret KVPath) (*api.KVMetadata, error) {
+	if err := waitLimit(ctx); err != nil {
+		return nil, err
+	}
+	return client.KVv2(secret.Mount.Path).GetMetadata(ctx, secret.Secret)
+}
+
+type ConflictPolicy string
+
+const (
+	ConflictSkip      ConflictPolicy = "skip"
+	ConflictOverwrite ConflictPolicy = "overwrite"
+	ConflictMerge     ConflictPolicy = "merge"
+	ConflictFail      ConflictPolicy = "fail"
+)
+
+func ParseConflictPolicy(policy string) (ConflictPolicy, error) {
+	switch ConflictPolicy(strings.ToLower(policy)) {
+	case "", ConflictFail:
+		return ConflictFail, nil
+	case ConflictSkip:
+		return ConflictSkip, nil
+	case ConflictOverwrite:
+		return ConflictOverwrite, nil
+	case ConflictMerge:
+		return ConflictMerge, nil
+	default:
+		return "", fmt.Errorf("invalid conflict policy '%s' (use skip, overwrite, merge or fail)", policy)
+	}
+}
+
+// PathRemap troca o prefixo From por To nos caminhos do bundle. Os dois lados aceitam
+// caminhos com ou sem data/.
+type PathRemap struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+type ImportStatus string
+
+const (
+	ImportWritten      ImportStatus = "written"
+	ImportSkipped      ImportStatus = "skipped"
+	ImportConflict     ImportStatus = "conflict"
+	ImportInvalid      ImportStatus = "invalid"
+	ImportFailed       ImportStatus = "failed"
+	ImportNotAttempted ImportStatus = "not_attempted"
+)
+
+type ImportResult struct {
+	Source  string       `json:"source"`
+	Path    string       `json:"path"`
+	Status  ImportStatus `json:"status"`
+	Version int          `json:"version,omitempty"`
+	Error   string       `json:"error,omitempty"`
+}
+
+var ErrImportConflict = errors.New("import aborted: some target paths already exist")
+
+type importEntry struct {
+	kvPath KVPath
+	entry  BundleEntry
+}
+
+// ImportBundle grava as entradas do bundle nos caminhos remapeados. Com ConflictFail
+// nada é gravado se algum destino já existir; as demais políticas decidem por caminho.
+// Se ctx for cancelado, as entradas restantes ficam como not_attempted.
+func ImportBundle(ctx context.Context, client *api.Client, bundle *Bundle, remaps []PathRemap, policy ConflictPolicy) ([]ImportResult, error) {
+	ctx = WithRateLimit(ctx)
+
+	rules, err := resolveRemaps(client, remaps)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]ImportResult, len(bundle.Entries))
+	entries := make([]importEntry, len(bundle.Entries))
+	seen := make(map[string]int)
+	invalid := false
+
+	for i, entry := range bundle.Entries {
+		results[i] = ImportResult{Source: entry.Path, Path: entry.Path, Status: ImportNotAttempted}
+
+		kvPath, err := importTarget(client, entry, rules)
+		if err == nil {
+			results[i].Path = kvPath.DataPath()
+			if first, dup := seen[kvPath.String()]; dup {
+				err = fmt.Errorf("target path duplicated in bundle (entry %d)", first)
+			} else {
+				seen[kvPath.String()] = i
+			}
+		}
+
+		if err != nil {
+			results[i].Status = ImportInvalid
+			results[i].Error = err.Error()
+			invalid = true
+			continue
+		}
+
+		entries[i] = importEntry{kvPath: kvPath, entry: entry}
+	}
+
+	if invalid {
+		return results, fmt.Errorf("%w: invalid bundle entries", ErrBatchInvalid)
+	}
+
+	if policy == ConflictFail {
+		conflict := false
+		for i := range entries {
+			data, _, err := readSecretDataWithContext(ctx, client, entries[i].kvPath)
+			switch {
+			case err != nil:
+				return results, fmt.Errorf("failed to check '%s': %w", entries[i].kvPath, err)
+			case data != nil:
+				results[i].Status = ImportConflict
+				results[i].Error = "secret already exists"
+				conflict = true
+			}
+		}
+		if conflict {
+			return results, ErrImportConflict
+		}
+	}
+
+	opts := StoreOptions{Mode: ReplaceWrite}
+	switch policy {
+	case ConflictSkip, ConflictFail:
+		opts.Mode = CreateOnlyWrite
+	case ConflictMerge:
+		opts.Mode = MergeWrite
+	}
+
+	for i := range entries {
+		if ctx.Err() != nil {
+			return results, ctx.Err()
+		}
+
+		version, err := writeSecretDataWithContext(ctx, client, entries[i].kvPath, entries[i].entry.Data, opts)
+		switch {
+		case errors.Is(err, ErrSecretExists) && policy == ConflictSkip:
+			results[i].Status = ImportSkipped
+			continue
+		case errors.Is(err, ErrSecretExists):
+			results[i].Status = ImportConflict
+			results[i].Error = err.Error()
+			continue
+		case err != nil:
+			results[i].Status = ImportFailed
+			results[i].Error = err.Error()
+			continue
+		}
+
+		results[i].Status = ImportWritten
+		results[i].Version = version
+
+		if len(entries[i].entry.CustomMetadata) > 0 && entries[i].kvPath.Mount.Version == 2 {
+			err := waitLimit(ctx)
+			if err == nil {
+				err = client.KVv2(entries[i].kvPath.Mount.Path).PatchMetadata(ctx, entries[i].kvPath.Secret, api.KVMetadataPatchInput{
+					CustomMetadata: entries[i].entry.CustomMetadata,
+				})
+			}
+			if err != nil {
+				results[i].Error = fmt.Sprintf("data written, but custom_metadata failed: %v", err)
+			}
+		}
+	}
+
+	return results, nil
+}
+
+// resolveRemaps normaliza os prefixos de origem para o caminho lógico; o destino é
+// resolvido junto com cada caminho em importTarget.
+func resolveRemaps(client *api.Client, remaps []PathRemap) ([]PathRemap, error) {
+	rules := make([]PathRemap, 0, len(remaps))
+	for _, remap := range remaps {
+		if remap.From == "" || remap.To == "" {
+			return nil, fmt.Errorf("remap requires from and to")
+		}
+		rules = append(rules, PathRemap{From: logicalPath(client, remap.From), To: joinPath(remap.To)})
+	}
+
+	// O prefixo mais longo vence quando mais de uma regra se aplica
+	sort.SliceStable(rules, func(i, j int) bool {
+		return len(rules[i].From) > len(rules[j].From)
+	})
+	return rules, nil
+}
+
+// logicalPath remove o data/ do KV v2 quando o mount é conhecido neste Vault; caminhos
+// de mounts que só existem no cluster de origem são usados como estão.
+func logicalPath(client *api.Client, path string) string {
+	if kvPath, err := ResolvePath(client, path); err == nil {
+		return kvPath.String()
+	}
+	return joinPath(path)
+}
+
+func importTarget(client *api.Client, entry BundleEntry, rules []PathRemap) (KVPath, error) {
+	if entry.Path == "" || len(entry.Data) == 0 {
+		return KVPath{}, fmt.Errorf("path and data are required")
+	}
+
+	target := logicalPath(client, entry.Path)
+	for _, rule := range rules {
+		if target == rule.From || strings.HasPrefix(target, rule.From+"/") {
+			target = joinPath(rule.To, strings.TrimPrefix(target, rule.From))
+			break
+		}
+	}
+
+	kvPath, err := ResolvePath(client, target)
+	if err != nil {
+		return KVPath{}, err
+	}
+	if kvPath.Secret == "" {
+		return KVPath{}, fmt.Errorf("target '%s' points to a mount, not a secret", target)
+	}
+	if err := checkProtected(kvPath); err != nil {
+		return KVPath{}, err
+	}
+	return kvPath, nil
+}
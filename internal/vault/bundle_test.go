@@ -0,0 +1,108 @@
+package vault
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestImportTarget(t *testing.T) {
+	client := offlineClient(t)
+	withProtectedPaths(t, "kv-v2/break-glass")
+
+	data := map[string]interface{}{"KEY": "value"}
+
+	tests := []struct {
+		name       string
+		path       string
+		data       map[string]interface{}
+		remaps     []PathRemap
+		wantPath   string
+		wantErr    bool
+		wantProtec bool
+	}{
+		{name: "no remap", path: "kv-v2/data/app/config", wantPath: "kv-v2/data/app/config"},
+		{name: "logical path", path: "kv-v2/app/config", wantPath: "kv-v2/data/app/config"},
+		{
+			name:     "remap with data prefix",
+			path:     "kv-v2/data/hml/app/config",
+			remaps:   []PathRemap{{From: "kv-v2/data/hml", To: "kv-v2/prd"}},
+			wantPath: "kv-v2/data/prd/app/config",
+		},
+		{
+			name:     "remap across versions",
+			path:     "kv-v2/data/hml/app",
+			remaps:   []PathRemap{{From: "kv-v2/hml", To: "kv-v1/prd"}},
+			wantPath: "kv-v1/prd/app",
+		},
+		{
+			name: "longest prefix wins",
+			path: "kv-v2/data/hml/app/config",
+			remaps: []PathRemap{
+				{From: "kv-v2/hml", To: "kv-v2/prd"},
+				{From: "kv-v2/hml/app", To: "team/app"},
+			},
+			wantPath: "team/data/app/config",
+		},
+		{
+			name:     "prefix matches whole segments",
+			path:     "kv-v2/data/app2/config",
+			remaps:   []PathRemap{{From: "kv-v2/app", To: "kv-v2/other"}},
+			wantPath: "kv-v2/data/app2/config",
+		},
+		{
+			name:     "source mount unknown in this cluster",
+			path:     "old-cluster/data/hml/app",
+			remaps:   []PathRemap{{From: "old-cluster/data/hml", To: "kv-v2/hml"}},
+			wantPath: "kv-v2/data/hml/app",
+		},
+		{
+			name:    "target is a mount",
+			path:    "kv-v2/data/app",
+			remaps:  []PathRemap{{From: "kv-v2/app", To: "kv-v2"}},
+			wantErr: true,
+		},
+		{
+			name:       "protected target",
+			path:       "kv-v2/data/hml/admin",
+			remaps:     []PathRemap{{From: "kv-v2/hml", To: "kv-v2/break-glass"}},
+			wantErr:    true,
+			wantProtec: true,
+		},
+		{name: "missing data", path: "kv-v2/app/config", data: map[string]interface{}{}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules, err := resolveRemaps(client, tt.remaps)
+			if err != nil {
+				t.Fatalf("resolveRemaps: %v", err)
+			}
+
+			entry := BundleEntry{Path: tt.path, Data: data}
+			if tt.data != nil {
+				entry.Data = tt.data
+			}
+
+			kvPath, err := importTarget(client, entry, rules)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("importTarget = %v, wantErr %v", err, tt.wantErr)
+			}
+			if errors.Is(err, ErrProtectedPath) != tt.wantProtec {
+				t.Errorf("importTarget = %v, want protected %v", err, tt.wantProtec)
+			}
+			if err == nil && kvPath.DataPath() != tt.wantPath {
+				t.Errorf("target = %q, want %q", kvPath.DataPath(), tt.wantPath)
+			}
+		})
+	}
+}
+
+func TestResolveRemapsRequiresBothSides(t *testing.T) {
+	client := offlineClient(t)
+
+	for _, remap := range []PathRemap{{From: "kv-v2/hml"}, {To: "kv-v2/prd"}} {
+		if _, err := resolveRemaps(client, []PathRemap{remap}); err == nil {
+			t.Errorf("resolveRemaps(%+v) succeeded, want an error", remap)
+		}
+	}
+}
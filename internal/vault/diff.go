@@ -0,0 +1,123 @@
+package vault
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/vault/api"
+)
+
+const maskedValue = "********"
+
+type KeyChange string
+
+const (
+	KeyAdded   KeyChange = "added"
+	KeyRemoved KeyChange = "removed"
+	KeyChanged KeyChange = "changed"
+)
+
+type KeyDiff struct {
+	Key      string    `json:"key"`
+	Change   KeyChange `json:"change"`
+	OldValue string    `json:"old_value,omitempty"`
+	NewValue string    `json:"new_value,omitempty"`
+}
+
+type SecretDiff struct {
+	Path           string    `json:"path"`
+	Exists         bool      `json:"exists"`
+	CurrentVersion int       `json:"current_version,omitempty"`
+	Mode           WriteMode `json:"mode,omitempty"`
+	Changes        []KeyDiff `json:"changes"`
+	Conflict       string    `json:"conflict,omitempty"`
+	Error          string    `json:"error,omitempty"`
+}
+
+// PreviewStore mostra o que StoreBatch faria com items sem gravar nada.
+func PreviewStore(client *api.Client, items []BatchItem, showValues bool) []SecretDiff {
+	diffs := make([]SecretDiff, len(items))
+
+	for i, item := range items {
+		diffs[i] = SecretDiff{Path: item.Path, Changes: []KeyDiff{}}
+
+		entry, err := validateBatchItem(client, item)
+		if err != nil {
+			diffs[i].Error = err.Error()
+			continue
+		}
+
+		diffs[i].Path = entry.kvPath.DataPath()
+		diffs[i].Mode = entry.opts.Mode
+
+		current, version, err := readSecretData(client, entry.kvPath)
+		if err != nil {
+			diffs[i].Error = fmt.Sprintf("failed to read current secret: %v", err)
+			continue
+		}
+
+		diffs[i].Exists = current != nil
+		diffs[i].CurrentVersion = version
+
+		switch {
+		case entry.opts.Mode == CreateOnlyWrite && current != nil:
+			diffs[i].Conflict = "secret already exists"
+		case entry.opts.CAS != nil && *entry.opts.CAS != version:
+			diffs[i].Conflict = fmt.Sprintf("cas %d does not match current version %d", *entry.opts.CAS, version)
+		}
+
+		diffs[i].Changes = DiffData(current, entry.data, entry.opts.Mode == MergeWrite, showValues)
+	}
+
+	return diffs
+}
+
+// DiffData compara os dados atuais com os desejados. Em merge, chaves ausentes em
+// desired são mantidas e por isso não aparecem como removidas.
+func DiffData(current, desired map[string]interface{}, merge, showValues bool) []KeyDiff {
+	changes := []KeyDiff{}
+
+	for key, newValue := range desired {
+		oldValue, exists := current[key]
+		switch {
+		case !exists:
+			changes = append(changes, keyDiff(key, KeyAdded, nil, newValue, showValues))
+		case fmt.Sprint(oldValue) != fmt.Sprint(newValue):
+			changes = append(changes, keyDiff(key, KeyChanged, oldValue, newValue, showValues))
+		}
+	}
+
+	if !merge {
+		for key, oldValue := range current {
+			if _, exists := desired[key]; !exists {
+				changes = append(changes, keyDiff(key, KeyRemoved, oldValue, nil, showValues))
+			}
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Key < changes[j].Key
+	})
+
+	return changes
+}
+
+func keyDiff(key string, change KeyChange, oldValue, newValue interface{}, showValues bool) KeyDiff {
+	diff := KeyDiff{Key: key, Change: change}
+
+	if oldValue != nil {
+		diff.OldValue = displayValue(oldValue, showValues)
+	}
+	if newValue != nil {
+		diff.NewValue = displayValue(newValue, showValues)
+	}
+
+	return diff
+}
+
+func displayValue(value interface{}, showValues bool) string {
+	if !showValues {
+		return maskedValue
+	}
+	return fmt.Sprint(value)
+}
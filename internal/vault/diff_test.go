@@ -0,0 +1,69 @@
+package vault
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffData(t *testing.T) {
+	current := map[string]interface{}{"HOST": "db01", "PORT": "5432", "USER": "app"}
+
+	tests := []struct {
+		name       string
+		desired    map[string]interface{}
+		merge      bool
+		showValues bool
+		want       []KeyDiff
+	}{
+		{
+			name:    "identical",
+			desired: map[string]interface{}{"HOST": "db01", "PORT": "5432", "USER": "app"},
+			want:    []KeyDiff{},
+		},
+		{
+			name:    "replace masks values",
+			desired: map[string]interface{}{"HOST": "db02", "PORT": "5432", "SSL": "true"},
+			want: []KeyDiff{
+				{Key: "HOST", Change: KeyChanged, OldValue: maskedValue, NewValue: maskedValue},
+				{Key: "SSL", Change: KeyAdded, NewValue: maskedValue},
+				{Key: "USER", Change: KeyRemoved, OldValue: maskedValue},
+			},
+		},
+		{
+			name:    "merge keeps missing keys",
+			desired: map[string]interface{}{"HOST": "db02"},
+			merge:   true,
+			want: []KeyDiff{
+				{Key: "HOST", Change: KeyChanged, OldValue: maskedValue, NewValue: maskedValue},
+			},
+		},
+		{
+			name:       "show values",
+			desired:    map[string]interface{}{"HOST": "db02", "PORT": 5432, "USER": "app"},
+			showValues: true,
+			want: []KeyDiff{
+				{Key: "HOST", Change: KeyChanged, OldValue: "db01", NewValue: "db02"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := DiffData(current, tt.desired, tt.merge, tt.showValues)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DiffData = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffDataNewSecret(t *testing.T) {
+	got := DiffData(nil, map[string]interface{}{"B": "2", "A": "1"}, false, true)
+	want := []KeyDiff{
+		{Key: "A", Change: KeyAdded, NewValue: "1"},
+		{Key: "B", Change: KeyAdded, NewValue: "2"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DiffData = %+v, want %+v", got, want)
+	}
+}
@@ -1,16 +1,25 @@
 package vault
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"sort"
 	"strings"
+	"sync"
 
 	"github.com/hashicorp/vault/api"
 )
 
+// OldValue e NewValue só são preenchidos quando a operação pede explicitamente para
+// exibir valores (ex: reescrita de hostnames); nunca na troca de senhas.
 type PasswordUpdateResult struct {
-	Path  string `json:"path"`
-	Key   string `json:"key"`
-	Error string `json:"error,omitempty"`
+	Path        string `json:"path"`
+	Key         string `json:"key"`
+	Occurrences int    `json:"occurrences,omitempty"`
+	OldValue    string `json:"old_value,omitempty"`
+	NewValue    string `json:"new_value,omitempty"`
+	Error       string `json:"error,omitempty"`
 }
 
 type OperationMode string
@@ -20,112 +29,79 @@ const (
 	EditMode OperationMode = "edit"
 )
 
-func SearchAndReplacePasswordDirect(basePath, oldPassword, newPassword string, mode OperationMode) ([]PasswordUpdateResult, error) {
-	client, err := getClient()
-	if err != nil {
-		return nil, err
-	}
+const maxCASRetries = 3
+
+func SearchAndReplacePasswordDirect(ctx context.Context, client *api.Client, basePath, oldPassword, newPassword string, mode OperationMode, observer Observer) ([]PasswordUpdateResult, error) {
+	return SearchAndReplaceMatching(ctx, client, basePath, ExactValue(oldPassword), newPassword, mode, observer, TreeOptions{})
+}
 
+// SearchAndReplaceMatching troca por newPassword todo valor aceito por matcher; permite
+// localizar a senha antiga pelo hash sem recebê-la em texto puro.
+func SearchAndReplaceMatching(ctx context.Context, client *api.Client, basePath string, matcher Matcher, newPassword string, mode OperationMode, observer Observer, opts TreeOptions) ([]PasswordUpdateResult, error) {
 	if mode != ListMode && mode != EditMode {
 		return nil, fmt.Errorf("modo de operação inválido: %s (use 'list' ou 'edit')", mode)
 	}
 
-	if mode == ListMode {
-		fmt.Printf("Executando em modo LISTA: apenas buscando ocorrências sem fazer alterações\n")
-	} else {
-		fmt.Printf("Executando em modo EDIÇÃO: buscando e substituindo ocorrências\n")
-	}
-
-	var allUpdates []PasswordUpdateResult
-	var processedPaths = make(map[string]bool)
-
-	basePath = strings.TrimSuffix(basePath, "/")
-	basePath = normalizePathSlashes(basePath)
+	return rewriteTree(ctx, client, basePath, ReplaceMatching(matcher, newPassword), mode, false, observer, opts)
+}
 
-	baseVariations := generatePathVariations(basePath)
+// TreeOptions ajusta as operações recursivas: Filter restringe a varredura e RunLog,
+// se informado, registra as alterações do modo edit para rollback.
+type TreeOptions struct {
+	Filter PathFilter
+	RunLog *RunLog
+}
 
-	for _, variant := range baseVariations {
-		variant = normalizePathSlashes(variant)
+func rewriteTree(ctx context.Context, client *api.Client, basePath string, rewriter Rewriter, mode OperationMode, showValues bool, observer Observer, opts TreeOptions) ([]PasswordUpdateResult, error) {
+	if err := opts.Filter.Validate(); err != nil {
+		return nil, err
+	}
 
-		list, err := client.Logical().List(variant)
-		if err != nil {
-			continue
-		}
+	emit(observer, Event{Type: EventRunStarted, Path: basePath, Message: string(mode)})
 
-		if list == nil || list.Data == nil {
-			continue
-		}
+	root, err := ResolvePath(client, basePath)
+	if err != nil {
+		return nil, err
+	}
 
-		keys, ok := list.Data["keys"].([]interface{})
-		if !ok {
-			continue
-		}
+	var mu sync.Mutex
+	var allUpdates []PasswordUpdateResult
 
-		fmt.Printf("Encontrados %d itens em %s\n", len(keys), variant)
+	walker := NewWalker(client)
+	walker.Filter = opts.Filter
+	walker.Observer = observer
 
-		for _, keyRaw := range keys {
-			key, ok := keyRaw.(string)
-			if !ok {
-				continue
-			}
+	err = walker.Walk(ctx, root, func(ctx context.Context, secret KVPath) error {
+		updates := processSecret(ctx, client, secret, rewriter, mode, showValues, observer, opts.RunLog)
 
-			if strings.HasSuffix(key, "/") {
-				childPath := normalizePathSlashes(fmt.Sprintf("%s/%s", variant, key))
-				childPath = strings.TrimSuffix(childPath, "/")
-
-				fmt.Printf("Explorando subdiretório: %s\n", childPath)
-
-				childUpdates, _ := SearchAndReplacePasswordDirect(childPath, oldPassword, newPassword, mode)
-				for _, update := range childUpdates {
-					uniqueKey := update.Path + ":" + update.Key
-					if !processedPaths[uniqueKey] {
-						allUpdates = append(allUpdates, update)
-						processedPaths[uniqueKey] = true
-					}
-				}
-			} else {
-				secretPath := normalizePathSlashes(fmt.Sprintf("%s/%s", variant, key))
-				secretVariations := generateSecretPathVariations(secretPath)
-
-				fmt.Printf("Verificando segredo: %s\n", key)
-
-				for _, secretVariant := range secretVariations {
-					secretVariant = normalizePathSlashes(secretVariant)
-					updates := processSecret(client, secretVariant, oldPassword, newPassword, mode)
-					if len(updates) > 0 {
-						for _, update := range updates {
-							uniqueKey := update.Path + ":" + update.Key
-							if !processedPaths[uniqueKey] {
-								allUpdates = append(allUpdates, update)
-								processedPaths[uniqueKey] = true
-							}
-						}
-						break
-					}
-				}
-			}
-		}
+		mu.Lock()
+		allUpdates = append(allUpdates, updates...)
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		return allUpdates, err
 	}
 
-	secretVariations := generateSecretPathVariations(basePath)
-	for _, secretVariant := range secretVariations {
-		secretVariant = normalizePathSlashes(secretVariant)
-		updates := processSecret(client, secretVariant, oldPassword, newPassword, mode)
-		if len(updates) > 0 {
-			for _, update := range updates {
-				uniqueKey := update.Path + ":" + update.Key
-				if !processedPaths[uniqueKey] {
-					allUpdates = append(allUpdates, update)
-					processedPaths[uniqueKey] = true
-				}
-			}
-			break
-		}
+	if root.Secret != "" && !opts.Filter.excludes(root) && opts.Filter.includes(root) {
+		emit(observer, Event{Type: EventCheckSecret, Path: root.DataPath()})
+		updates := processSecret(ctx, client, root, rewriter, mode, showValues, observer, opts.RunLog)
+		allUpdates = append(allUpdates, updates...)
 	}
 
+	sortUpdates(allUpdates)
 	return allUpdates, nil
 }
 
+func sortUpdates(updates []PasswordUpdateResult) {
+	sort.Slice(updates, func(i, j int) bool {
+		if updates[i].Path != updates[j].Path {
+			return updates[i].Path < updates[j].Path
+		}
+		return updates[i].Key < updates[j].Key
+	})
+}
+
 func normalizePathSlashes(path string) string {
 	for strings.Contains(path, "//") {
 		path = strings.ReplaceAll(path, "//", "/")
@@ -133,112 +109,97 @@ func normalizePathSlashes(path string) string {
 	return path
 }
 
-func generatePathVariations(path string) []string {
-	variations := []string{
-		path,
-		path + "/",
-	}
-
-	parts := strings.SplitN(path, "/", 2)
-	if len(parts) > 1 {
-		metadataPath := fmt.Sprintf("%s/metadata/%s", parts[0], parts[1])
-		variations = append(variations, metadataPath)
-		variations = append(variations, metadataPath+"/")
-	}
-
-	return variations
-}
+func processSecret(ctx context.Context, client *api.Client, kvPath KVPath, rewriter Rewriter, mode OperationMode, showValues bool, observer Observer, runLog *RunLog) []PasswordUpdateResult {
+	path := kvPath.DataPath()
 
-func generateSecretPathVariations(path string) []string {
-	variations := []string{
-		path,
-	}
+	for attempt := 1; ; attempt++ {
+		var updates []PasswordUpdateResult
 
-	parts := strings.SplitN(path, "/", 2)
-	if len(parts) > 1 {
-		if strings.Contains(path, "/metadata/") {
-			dataPath := strings.Replace(path, "/metadata/", "/data/", 1)
-			variations = append(variations, dataPath)
-		} else if !strings.Contains(path, "/data/") {
-			dataPath := fmt.Sprintf("%s/data/%s", parts[0], parts[1])
-			variations = append(variations, dataPath)
+		dataMap, version, err := readSecretDataWithContext(ctx, client, kvPath)
+		if err != nil {
+			if ctx.Err() == nil {
+				emit(observer, Event{Type: EventReadFailed, Path: path, Error: err.Error()})
+			}
+			return updates
+		}
+		if dataMap == nil {
+			return updates
 		}
-	}
-
-	return variations
-}
-
-func processSecret(client *api.Client, path string, oldPassword, newPassword string, mode OperationMode) []PasswordUpdateResult {
-	var updates []PasswordUpdateResult
-
-	path = normalizePathSlashes(path)
 
-	secret, err := client.Logical().Read(path)
-	if err != nil {
-		return updates
-	}
+		var updated bool
+		updatedData := make(map[string]interface{})
 
-	if secret == nil || secret.Data == nil {
-		return updates
-	}
+		for key, value := range dataMap {
+			updatedData[key] = value
 
-	var dataMap map[string]interface{}
-	var format string
+			strValue, ok := value.(string)
+			if !ok {
+				continue
+			}
 
-	if data, ok := secret.Data["data"].(map[string]interface{}); ok {
-		format = "KV v2"
-		dataMap = data
-	} else {
-		format = "KV v1 ou direto"
-		dataMap = secret.Data
-	}
+			newValue, occurrences := rewriter.Rewrite(key, strValue)
+			if occurrences == 0 {
+				continue
+			}
 
-	var updated bool
-	updatedData := make(map[string]interface{})
+			if mode == EditMode && newValue != strValue {
+				updatedData[key] = newValue
+				updated = true
+			}
 
-	for key, value := range dataMap {
-		updatedData[key] = value
+			update := PasswordUpdateResult{
+				Path:        path,
+				Key:         key,
+				Occurrences: occurrences,
+			}
+			if showValues {
+				update.OldValue = strValue
+				update.NewValue = newValue
+			}
+			updates = append(updates, update)
+		}
 
-		if strValue, ok := value.(string); ok {
-			if strValue == oldPassword {
-				if mode == EditMode {
-					fmt.Printf("SENHA ENCONTRADA em %s, campo: %s (será alterada)\n", path, key)
+		// Em caso de nova tentativa após conflito, as ocorrências já foram notificadas
+		if attempt == 1 {
+			for _, update := range updates {
+				emit(observer, Event{Type: EventMatchFound, Path: path, Key: update.Key, Message: string(mode)})
+			}
+		}
 
-					updatedData[key] = newPassword
-					updated = true
-				} else {
-					fmt.Printf("SENHA ENCONTRADA em %s, campo: %s (modo lista: sem alteração)\n", path, key)
-				}
+		if !updated || mode != EditMode {
+			return updates
+		}
 
-				updates = append(updates, PasswordUpdateResult{
-					Path: path,
-					Key:  key,
-				})
+		if err := checkProtected(kvPath); err != nil {
+			emit(observer, Event{Type: EventWriteFailed, Path: path, Count: len(updates), Error: err.Error()})
+			for i := range updates {
+				updates[i].Error = err.Error()
 			}
+			return updates
 		}
-	}
 
-	if updated && mode == EditMode {
-		var payload map[string]interface{}
+		opts := StoreOptions{Mode: ReplaceWrite}
+		if kvPath.Mount.Version == 2 {
+			opts.CAS = &version
+		}
 
-		if format == "KV v2" {
-			payload = map[string]interface{}{
-				"data": updatedData,
-			}
-		} else {
-			payload = updatedData
+		written, err := writeSecretDataWithContext(ctx, client, kvPath, updatedData, opts)
+		if errors.Is(err, ErrCASMismatch) && attempt < maxCASRetries {
+			emit(observer, Event{Type: EventWriteConflict, Path: path, Count: version, Error: err.Error()})
+			continue
 		}
 
-		_, err := client.Logical().Write(path, payload)
 		if err != nil {
-			fmt.Printf("ERRO ao atualizar segredo %s: %v\n", path, err)
+			emit(observer, Event{Type: EventWriteFailed, Path: path, Count: len(updates), Error: err.Error()})
 
-			lastIdx := len(updates) - 1
-			updates[lastIdx].Error = err.Error()
+			for i := range updates {
+				updates[i].Error = err.Error()
+			}
 		} else {
-			fmt.Printf("Atualização bem-sucedida em %s\n", path)
+			runLog.record(kvPath, version, dataMap, written, updatedData)
+			emit(observer, Event{Type: EventWriteOK, Path: path, Count: len(updates)})
 		}
-	}
 
-	return updates
+		return updates
+	}
 }
@@ -0,0 +1,74 @@
+package vault
+
+import (
+	"context"
+	"devops-go-vault-api/internal/vault/vaulttest"
+	"testing"
+)
+
+func countEvents(events []Event, eventType EventType) int {
+	count := 0
+	for _, event := range events {
+		if event.Type == eventType {
+			count++
+		}
+	}
+	return count
+}
+
+func TestReplaceRetriesAfterCASConflict(t *testing.T) {
+	srv := vaulttest.NewServer(t)
+	srv.Put("app/config", map[string]interface{}{"DB_PASSWORD": "senha-antiga"})
+	// Entre a leitura e a escrita, outro cliente grava uma chave nova
+	srv.InterleaveWrites("app/config", map[string]interface{}{"DB_PASSWORD": "senha-antiga", "OTHER": "concorrente"}, 1)
+
+	collector := &Collector{}
+	updates, err := SearchAndReplaceMatching(context.Background(), srv.Client(t), vaulttest.Mount+"/app", ExactValue("senha-antiga"), "senha-nova", EditMode, collector, TreeOptions{})
+	if err != nil {
+		t.Fatalf("SearchAndReplaceMatching: %v", err)
+	}
+
+	if len(updates) != 1 || updates[0].Error != "" {
+		t.Fatalf("updates = %+v, want one successful update", updates)
+	}
+	// A nova tentativa relê o segredo e preserva a escrita concorrente
+	data := srv.Data("app/config")
+	if data["DB_PASSWORD"] != "senha-nova" || data["OTHER"] != "concorrente" {
+		t.Errorf("data = %v, want the new password merged over the concurrent write", data)
+	}
+	if srv.Version("app/config") != 3 {
+		t.Errorf("version = %d, want 3", srv.Version("app/config"))
+	}
+
+	events := collector.Events()
+	if countEvents(events, EventWriteConflict) != 1 || countEvents(events, EventWriteOK) != 1 {
+		t.Errorf("events = %+v, want one conflict followed by a successful write", events)
+	}
+	if countEvents(events, EventMatchFound) != 1 {
+		t.Errorf("match_found emitted %d times, want it only on the first attempt", countEvents(events, EventMatchFound))
+	}
+}
+
+func TestReplaceReportsWriteFailedAfterExhaustingRetries(t *testing.T) {
+	srv := vaulttest.NewServer(t)
+	srv.Put("app/config", map[string]interface{}{"DB_PASSWORD": "senha-antiga"})
+	srv.InterleaveWrites("app/config", map[string]interface{}{"DB_PASSWORD": "senha-antiga"}, maxCASRetries)
+
+	collector := &Collector{}
+	updates, err := SearchAndReplaceMatching(context.Background(), srv.Client(t), vaulttest.Mount+"/app", ExactValue("senha-antiga"), "senha-nova", EditMode, collector, TreeOptions{})
+	if err != nil {
+		t.Fatalf("SearchAndReplaceMatching: %v", err)
+	}
+
+	if len(updates) != 1 || updates[0].Error == "" {
+		t.Fatalf("updates = %+v, want the update to carry the CAS error", updates)
+	}
+	if got := srv.Data("app/config")["DB_PASSWORD"]; got != "senha-antiga" {
+		t.Errorf("DB_PASSWORD = %v, want it untouched", got)
+	}
+
+	events := collector.Events()
+	if countEvents(events, EventWriteConflict) != maxCASRetries-1 || countEvents(events, EventWriteFailed) != 1 {
+		t.Errorf("events = %+v, want %d conflicts and one write_failed", events, maxCASRetries-1)
+	}
+}
@@ -0,0 +1,126 @@
+package vault
+
+import (
+	"encoding/json"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type EventType string
+
+const (
+	EventRunStarted    EventType = "run_started"
+	EventScanDir       EventType = "scan_dir"
+	EventListFailed    EventType = "list_failed"
+	EventReadFailed    EventType = "read_failed"
+	EventCheckSecret   EventType = "check_secret"
+	EventMatchFound    EventType = "match_found"
+	EventWriteOK       EventType = "write_ok"
+	EventWriteConflict EventType = "write_conflict"
+	EventWriteFailed   EventType = "write_failed"
+)
+
+// Event descreve um passo de uma operação em lote. Nunca carrega valores de segredos.
+type Event struct {
+	Time    time.Time `json:"time"`
+	Type    EventType `json:"type"`
+	Path    string    `json:"path,omitempty"`
+	Key     string    `json:"key,omitempty"`
+	Count   int       `json:"count,omitempty"`
+	Message string    `json:"message,omitempty"`
+	Error   string    `json:"error,omitempty"`
+}
+
+// Observer recebe os eventos de uma operação; Observe pode ser chamado em paralelo.
+type Observer interface {
+	Observe(event Event)
+}
+
+type ObserverFunc func(event Event)
+
+func (f ObserverFunc) Observe(event Event) {
+	f(event)
+}
+
+type multiObserver []Observer
+
+func (m multiObserver) Observe(event Event) {
+	for _, observer := range m {
+		observer.Observe(event)
+	}
+}
+
+func MultiObserver(observers ...Observer) Observer {
+	var list multiObserver
+	for _, observer := range observers {
+		if observer != nil {
+			list = append(list, observer)
+		}
+	}
+	return list
+}
+
+func emit(observer Observer, event Event) {
+	if observer == nil {
+		return
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	observer.Observe(event)
+}
+
+// Collector guarda os eventos recebidos para devolvê-los na resposta da requisição.
+type Collector struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+func (c *Collector) Observe(event Event) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.events = append(c.events, event)
+}
+
+func (c *Collector) Events() []Event {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Event(nil), c.events...)
+}
+
+// LogObserver escreve cada evento como uma linha JSON no log padrão.
+type LogObserver struct {
+	Operation string
+}
+
+func (l LogObserver) Observe(event Event) {
+	payload, err := json.Marshal(struct {
+		Operation string `json:"operation,omitempty"`
+		Event
+	}{l.Operation, event})
+	if err != nil {
+		return
+	}
+	log.Print(string(payload))
+}
+
+// Progress acumula os contadores de uma varredura em andamento e pode ser lido
+// enquanto ela executa.
+type Progress struct {
+	Scanned atomic.Int64
+	Matches atomic.Int64
+	Errors  atomic.Int64
+}
+
+func (p *Progress) Observe(event Event) {
+	switch event.Type {
+	case EventCheckSecret:
+		p.Scanned.Add(1)
+	case EventMatchFound:
+		p.Matches.Add(1)
+	case EventListFailed, EventReadFailed, EventWriteFailed:
+		p.Errors.Add(1)
+	}
+}
@@ -0,0 +1,87 @@
+package vault
+
+import (
+	"devops-go-vault-api/config"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+)
+
+var ErrProtectedPath = errors.New("path is protected")
+
+// PathFilter restringe as operações recursivas. Os globs são comparados com o caminho
+// lógico (mount/segredo, sem data/) e também valem para os diretórios acima dele, então
+// "secret/break-glass" exclui toda a subárvore. MaxDepth conta os níveis abaixo do
+// caminho base (1 = apenas os segredos diretamente nele); 0 não limita.
+type PathFilter struct {
+	Include  []string `json:"include,omitempty"`
+	Exclude  []string `json:"exclude,omitempty"`
+	MaxDepth int      `json:"max_depth,omitempty"`
+}
+
+func (f PathFilter) Validate() error {
+	for _, pattern := range append(append([]string{}, f.Include...), f.Exclude...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid path glob '%s': %v", pattern, err)
+		}
+	}
+	if f.MaxDepth < 0 {
+		return fmt.Errorf("max_depth must not be negative")
+	}
+	return nil
+}
+
+// excludes indica se o caminho (segredo ou diretório) deve ser ignorado por completo.
+func (f PathFilter) excludes(kvPath KVPath) bool {
+	return matchesAnyGlob(f.Exclude, kvPath.String())
+}
+
+func (f PathFilter) includes(kvPath KVPath) bool {
+	return len(f.Include) == 0 || matchesAnyGlob(f.Include, kvPath.String())
+}
+
+// descends indica se um diretório na profundidade depth ainda deve ser listado.
+func (f PathFilter) descends(depth int) bool {
+	return f.MaxDepth == 0 || depth < f.MaxDepth
+}
+
+// matchesAnyGlob compara p e cada diretório acima dele com os globs.
+func matchesAnyGlob(patterns []string, p string) bool {
+	if len(patterns) == 0 {
+		return false
+	}
+
+	segments := strings.Split(p, "/")
+	for i := len(segments); i > 0; i-- {
+		candidate := strings.Join(segments[:i], "/")
+		for _, pattern := range patterns {
+			if matched, _ := path.Match(strings.Trim(pattern, "/"), candidate); matched {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// checkProtected devolve ErrProtectedPath se kvPath estiver em VAULT_PROTECTED_PATHS.
+func checkProtected(kvPath KVPath) error {
+	if IsProtected(kvPath) {
+		return fmt.Errorf("%w: '%s' is in VAULT_PROTECTED_PATHS", ErrProtectedPath, kvPath)
+	}
+	return nil
+}
+
+// IsProtected indica se o caminho está sob um dos prefixos de VAULT_PROTECTED_PATHS,
+// que nenhuma escrita ou deleção da API pode alterar, simples ou recursiva. Aceita
+// prefixos com ou sem data/.
+func IsProtected(kvPath KVPath) bool {
+	for _, prefix := range config.VaultProtectedPaths {
+		for _, candidate := range []string{kvPath.String(), kvPath.DataPath()} {
+			if candidate == prefix || strings.HasPrefix(candidate, prefix+"/") {
+				return true
+			}
+		}
+	}
+	return false
+}
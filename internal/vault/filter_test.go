@@ -0,0 +1,121 @@
+package vault
+
+import (
+	"devops-go-vault-api/config"
+	"errors"
+	"testing"
+)
+
+func withProtectedPaths(t *testing.T, prefixes ...string) {
+	t.Helper()
+
+	previous := config.VaultProtectedPaths
+	config.VaultProtectedPaths = prefixes
+	t.Cleanup(func() { config.VaultProtectedPaths = previous })
+}
+
+func TestPathFilter(t *testing.T) {
+	secret := func(p string) KVPath {
+		return KVPath{Mount: KVMount{Path: "secret", Version: 2}, Secret: p}
+	}
+
+	tests := []struct {
+		name     string
+		filter   PathFilter
+		path     KVPath
+		excludes bool
+		includes bool
+	}{
+		{"no filter", PathFilter{}, secret("app/config"), false, true},
+		{"exclude subtree", PathFilter{Exclude: []string{"secret/break-glass"}}, secret("break-glass/root/token"), true, true},
+		{"exclude glob", PathFilter{Exclude: []string{"secret/*/legacy"}}, secret("app/legacy/db"), true, true},
+		{"exclude does not match sibling", PathFilter{Exclude: []string{"secret/app"}}, secret("app2/config"), false, true},
+		{"include glob", PathFilter{Include: []string{"secret/app/*"}}, secret("app/config"), false, true},
+		{"include misses", PathFilter{Include: []string{"secret/app/*"}}, secret("other/config"), false, false},
+		{"include with slashes", PathFilter{Include: []string{"/secret/app/"}}, secret("app/db/config"), false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.excludes(tt.path); got != tt.excludes {
+				t.Errorf("excludes = %v, want %v", got, tt.excludes)
+			}
+			if got := tt.filter.includes(tt.path); got != tt.includes {
+				t.Errorf("includes = %v, want %v", got, tt.includes)
+			}
+		})
+	}
+}
+
+func TestPathFilterDepth(t *testing.T) {
+	tests := []struct {
+		maxDepth int
+		depth    int
+		want     bool
+	}{
+		{0, 10, true},
+		{1, 0, true},
+		{1, 1, false},
+		{3, 2, true},
+		{3, 3, false},
+	}
+
+	for _, tt := range tests {
+		if got := (PathFilter{MaxDepth: tt.maxDepth}).descends(tt.depth); got != tt.want {
+			t.Errorf("MaxDepth %d: descends(%d) = %v, want %v", tt.maxDepth, tt.depth, got, tt.want)
+		}
+	}
+}
+
+func TestPathFilterValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		filter  PathFilter
+		wantErr bool
+	}{
+		{"empty", PathFilter{}, false},
+		{"valid globs", PathFilter{Include: []string{"secret/*"}, Exclude: []string{"secret/[ab]*"}}, false},
+		{"bad include", PathFilter{Include: []string{"secret/["}}, true},
+		{"bad exclude", PathFilter{Exclude: []string{"secret/[a-"}}, true},
+		{"negative depth", PathFilter{MaxDepth: -1}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.filter.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckProtected(t *testing.T) {
+	withProtectedPaths(t, "secret/break-glass", "kv-v1/root")
+
+	tests := []struct {
+		path KVPath
+		want bool
+	}{
+		{KVPath{Mount: KVMount{Path: "secret", Version: 2}, Secret: "break-glass"}, true},
+		{KVPath{Mount: KVMount{Path: "secret", Version: 2}, Secret: "break-glass/admin"}, true},
+		{KVPath{Mount: KVMount{Path: "secret", Version: 2}, Secret: "break-glass-2"}, false},
+		{KVPath{Mount: KVMount{Path: "kv-v1", Version: 1}, Secret: "root/token"}, true},
+		{KVPath{Mount: KVMount{Path: "kv-v1", Version: 1}, Secret: "app"}, false},
+	}
+
+	for _, tt := range tests {
+		err := checkProtected(tt.path)
+		if got := errors.Is(err, ErrProtectedPath); got != tt.want {
+			t.Errorf("checkProtected(%s) = %v, want protected %v", tt.path, err, tt.want)
+		}
+	}
+}
+
+func TestCheckProtectedWithDataPrefix(t *testing.T) {
+	withProtectedPaths(t, "secret/data/break-glass")
+
+	path := KVPath{Mount: KVMount{Path: "secret", Version: 2}, Secret: "break-glass/admin"}
+	if !IsProtected(path) {
+		t.Errorf("%s should be protected by a data/ prefix", path)
+	}
+}
@@ -0,0 +1,181 @@
+package vault
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"devops-go-vault-api/config"
+	"encoding/hex"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Matcher decide se o par chave/valor de um segredo deve ser considerado.
+type Matcher interface {
+	Match(key, value string) bool
+}
+
+type MatcherFunc func(key, value string) bool
+
+func (f MatcherFunc) Match(key, value string) bool {
+	return f(key, value)
+}
+
+func ExactValue(expected string) Matcher {
+	return MatcherFunc(func(key, value string) bool {
+		return value == expected
+	})
+}
+
+func ValueContains(substring string) Matcher {
+	return MatcherFunc(func(key, value string) bool {
+		return strings.Contains(value, substring)
+	})
+}
+
+func ValueRegex(re *regexp.Regexp) Matcher {
+	return MatcherFunc(func(key, value string) bool {
+		return re.MatchString(value)
+	})
+}
+
+// SHA256Value compara o SHA-256 do valor com digest, sem que o valor original trafegue.
+func SHA256Value(digest []byte) Matcher {
+	return MatcherFunc(func(key, value string) bool {
+		sum := sha256.Sum256([]byte(value))
+		return hmac.Equal(sum[:], digest)
+	})
+}
+
+// HMACValue compara o HMAC-SHA256 do valor, calculado com a chave do servidor, com digest.
+func HMACValue(hmacKey, digest []byte) Matcher {
+	return MatcherFunc(func(key, value string) bool {
+		return hmac.Equal(ValueHMAC(hmacKey, value), digest)
+	})
+}
+
+func ValueHMAC(hmacKey []byte, value string) []byte {
+	mac := hmac.New(sha256.New, hmacKey)
+	mac.Write([]byte(value))
+	return mac.Sum(nil)
+}
+
+// ParseDigest decodifica um SHA-256/HMAC-SHA256 em hexadecimal.
+func ParseDigest(hexDigest string) ([]byte, error) {
+	digest, err := hex.DecodeString(strings.TrimSpace(hexDigest))
+	if err != nil || len(digest) != sha256.Size {
+		return nil, fmt.Errorf("digest must be %d hex characters", sha256.Size*2)
+	}
+	return digest, nil
+}
+
+// NewHashMatcher aceita o SHA-256 ou o HMAC-SHA256 (hex) do valor procurado. O HMAC
+// exige VAULT_API_HMAC_KEY configurada no servidor.
+func NewHashMatcher(sha256Hex, hmacHex string) (Matcher, error) {
+	switch {
+	case sha256Hex != "" && hmacHex != "":
+		return nil, fmt.Errorf("use either sha256 or hmac, not both")
+	case sha256Hex != "":
+		digest, err := ParseDigest(sha256Hex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid sha256: %v", err)
+		}
+		return SHA256Value(digest), nil
+	case hmacHex != "":
+		if config.VaultAPIHMACKey == "" {
+			return nil, fmt.Errorf("hmac matching requires VAULT_API_HMAC_KEY on the server")
+		}
+		digest, err := ParseDigest(hmacHex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid hmac: %v", err)
+		}
+		return HMACValue([]byte(config.VaultAPIHMACKey), digest), nil
+	default:
+		return nil, nil
+	}
+}
+
+// KeyGlob compara o nome da chave com um glob (ex: *_PASSWORD), sem diferenciar maiúsculas.
+func KeyGlob(pattern string) Matcher {
+	pattern = strings.ToUpper(pattern)
+	return MatcherFunc(func(key, value string) bool {
+		matched, _ := path.Match(pattern, strings.ToUpper(key))
+		return matched
+	})
+}
+
+// AllOf exige que todos os matchers aceitem o par chave/valor.
+func AllOf(matchers ...Matcher) Matcher {
+	return MatcherFunc(func(key, value string) bool {
+		for _, matcher := range matchers {
+			if !matcher.Match(key, value) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// AnyOf aceita o par chave/valor se algum dos matchers aceitar.
+func AnyOf(matchers ...Matcher) Matcher {
+	return MatcherFunc(func(key, value string) bool {
+		for _, matcher := range matchers {
+			if matcher.Match(key, value) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+type SearchCriteria struct {
+	KeyGlob       string `json:"key_glob,omitempty"`
+	ValueEquals   string `json:"value_equals,omitempty"`
+	ValueContains string `json:"value_contains,omitempty"`
+	ValueRegex    string `json:"value_regex,omitempty"`
+	ValueSHA256   string `json:"value_sha256,omitempty"`
+	ValueHMAC     string `json:"value_hmac,omitempty"`
+}
+
+// NewMatcher combina todos os critérios informados; ao menos um é obrigatório.
+func NewMatcher(criteria SearchCriteria) (Matcher, error) {
+	var matchers []Matcher
+
+	if criteria.KeyGlob != "" {
+		if _, err := path.Match(criteria.KeyGlob, ""); err != nil {
+			return nil, fmt.Errorf("invalid key_glob '%s': %v", criteria.KeyGlob, err)
+		}
+		matchers = append(matchers, KeyGlob(criteria.KeyGlob))
+	}
+
+	if criteria.ValueEquals != "" {
+		matchers = append(matchers, ExactValue(criteria.ValueEquals))
+	}
+
+	if criteria.ValueContains != "" {
+		matchers = append(matchers, ValueContains(criteria.ValueContains))
+	}
+
+	if criteria.ValueRegex != "" {
+		re, err := regexp.Compile(criteria.ValueRegex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value_regex: %v", err)
+		}
+		matchers = append(matchers, ValueRegex(re))
+	}
+
+	hashMatcher, err := NewHashMatcher(criteria.ValueSHA256, criteria.ValueHMAC)
+	if err != nil {
+		return nil, err
+	}
+	if hashMatcher != nil {
+		matchers = append(matchers, hashMatcher)
+	}
+
+	if len(matchers) == 0 {
+		return nil, fmt.Errorf("at least one search criterion is required")
+	}
+
+	return AllOf(matchers...), nil
+}
@@ -0,0 +1,105 @@
+package vault
+
+import (
+	"crypto/sha256"
+	"devops-go-vault-api/config"
+	"encoding/hex"
+	"regexp"
+	"testing"
+)
+
+func TestMatchers(t *testing.T) {
+	sum := sha256.Sum256([]byte("senha-antiga"))
+	hmacKey := []byte("chave-do-servidor")
+
+	tests := []struct {
+		name    string
+		matcher Matcher
+		key     string
+		value   string
+		want    bool
+	}{
+		{"exact match", ExactValue("senha-antiga"), "DB_PASSWORD", "senha-antiga", true},
+		{"exact mismatch", ExactValue("senha-antiga"), "DB_PASSWORD", "senha-antiga-2", false},
+		{"contains", ValueContains("db01"), "DB_HOST", "db01.exemplo.com", true},
+		{"contains mismatch", ValueContains("db02"), "DB_HOST", "db01.exemplo.com", false},
+		{"regex", ValueRegex(regexp.MustCompile(`^postgres://`)), "DB_URL", "postgres://db01", true},
+		{"regex mismatch", ValueRegex(regexp.MustCompile(`^postgres://`)), "DB_URL", "mysql://db01", false},
+		{"sha256", SHA256Value(sum[:]), "DB_PASSWORD", "senha-antiga", true},
+		{"sha256 mismatch", SHA256Value(sum[:]), "DB_PASSWORD", "outra", false},
+		{"hmac", HMACValue(hmacKey, ValueHMAC(hmacKey, "senha-antiga")), "DB_PASSWORD", "senha-antiga", true},
+		{"hmac other key", HMACValue([]byte("outra-chave"), ValueHMAC(hmacKey, "senha-antiga")), "DB_PASSWORD", "senha-antiga", false},
+		{"key glob ignores case", KeyGlob("*_password"), "DB_PASSWORD", "x", true},
+		{"key glob mismatch", KeyGlob("*_PASSWORD"), "DB_USER", "x", false},
+		{"all of", AllOf(KeyGlob("DB_*"), ExactValue("x")), "DB_PASSWORD", "x", true},
+		{"all of partial", AllOf(KeyGlob("DB_*"), ExactValue("y")), "DB_PASSWORD", "x", false},
+		{"any of", AnyOf(KeyGlob("*TOKEN*"), KeyGlob("*PASSWORD*")), "DB_PASSWORD", "x", true},
+		{"any of none", AnyOf(KeyGlob("*TOKEN*"), KeyGlob("*PASSWORD*")), "DB_USER", "x", false},
+		{"any of empty", AnyOf(), "DB_PASSWORD", "x", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.matcher.Match(tt.key, tt.value); got != tt.want {
+				t.Errorf("Match(%q, %q) = %v, want %v", tt.key, tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewMatcher(t *testing.T) {
+	sum := sha256.Sum256([]byte("senha-antiga"))
+
+	tests := []struct {
+		name     string
+		criteria SearchCriteria
+		wantErr  bool
+		key      string
+		value    string
+		want     bool
+	}{
+		{"no criteria", SearchCriteria{}, true, "", "", false},
+		{"bad glob", SearchCriteria{KeyGlob: "["}, true, "", "", false},
+		{"bad regex", SearchCriteria{ValueRegex: "("}, true, "", "", false},
+		{"bad digest", SearchCriteria{ValueSHA256: "abc"}, true, "", "", false},
+		{"sha256 and hmac", SearchCriteria{ValueSHA256: hex.EncodeToString(sum[:]), ValueHMAC: hex.EncodeToString(sum[:])}, true, "", "", false},
+		{"sha256 with key glob", SearchCriteria{KeyGlob: "*PASSWORD*", ValueSHA256: hex.EncodeToString(sum[:])}, false, "DB_PASSWORD", "senha-antiga", true},
+		{"sha256 key mismatch", SearchCriteria{KeyGlob: "*PASSWORD*", ValueSHA256: hex.EncodeToString(sum[:])}, false, "DB_USER", "senha-antiga", false},
+		{"combined", SearchCriteria{KeyGlob: "*HOST*", ValueContains: "db01"}, false, "DB_HOST", "db01.exemplo.com", true},
+		{"combined key mismatch", SearchCriteria{KeyGlob: "*HOST*", ValueContains: "db01"}, false, "DB_URL", "db01.exemplo.com", false},
+		{"combined value mismatch", SearchCriteria{KeyGlob: "*HOST*", ValueRegex: "^db02"}, false, "DB_HOST", "db01.exemplo.com", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matcher, err := NewMatcher(tt.criteria)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewMatcher = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && matcher.Match(tt.key, tt.value) != tt.want {
+				t.Errorf("Match(%q, %q) = %v, want %v", tt.key, tt.value, !tt.want, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewHashMatcherRequiresHMACKey(t *testing.T) {
+	previous := config.VaultAPIHMACKey
+	t.Cleanup(func() { config.VaultAPIHMACKey = previous })
+
+	digest := hex.EncodeToString(ValueHMAC([]byte("chave-do-servidor"), "senha-antiga"))
+
+	config.VaultAPIHMACKey = ""
+	if _, err := NewHashMatcher("", digest); err == nil {
+		t.Error("hmac matching without VAULT_API_HMAC_KEY should fail")
+	}
+
+	config.VaultAPIHMACKey = "chave-do-servidor"
+	matcher, err := NewHashMatcher("", digest)
+	if err != nil {
+		t.Fatalf("NewHashMatcher: %v", err)
+	}
+	if !matcher.Match("DB_PASSWORD", "senha-antiga") {
+		t.Error("hmac matcher should match the original value")
+	}
+}
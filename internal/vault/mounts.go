@@ -0,0 +1,217 @@
+package vault
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/vault/api"
+)
+
+type KVMount struct {
+	Path    string `json:"path"`
+	Version int    `json:"version"`
+}
+
+// KVPath é um caminho lógico dentro de um mount KV, sem os prefixos data/ e metadata/
+// do KV v2. Os caminhos reais da API são montados a partir da versão do mount.
+type KVPath struct {
+	Mount  KVMount
+	Secret string
+}
+
+var (
+	mountsMu    sync.RWMutex
+	knownMounts = make(map[string]KVMount)
+)
+
+func (p KVPath) DataPath() string {
+	if p.Mount.Version == 2 {
+		return joinPath(p.Mount.Path, "data", p.Secret)
+	}
+	return joinPath(p.Mount.Path, p.Secret)
+}
+
+func (p KVPath) MetadataPath() string {
+	if p.Mount.Version == 2 {
+		return joinPath(p.Mount.Path, "metadata", p.Secret)
+	}
+	return joinPath(p.Mount.Path, p.Secret)
+}
+
+func (p KVPath) ListPath() string {
+	return p.MetadataPath() + "/"
+}
+
+func (p KVPath) Child(key string) KVPath {
+	return KVPath{
+		Mount:  p.Mount,
+		Secret: joinPath(p.Secret, strings.TrimSuffix(key, "/")),
+	}
+}
+
+func (p KVPath) String() string {
+	return joinPath(p.Mount.Path, p.Secret)
+}
+
+func joinPath(parts ...string) string {
+	var cleaned []string
+	for _, part := range parts {
+		part = strings.Trim(normalizePathSlashes(part), "/")
+		if part != "" {
+			cleaned = append(cleaned, part)
+		}
+	}
+	return strings.Join(cleaned, "/")
+}
+
+func registerMount(mount KVMount) {
+	mountsMu.Lock()
+	defer mountsMu.Unlock()
+	knownMounts[mount.Path] = mount
+}
+
+func mountVersion(options map[string]string) int {
+	if options != nil && options["version"] == "2" {
+		return 2
+	}
+	return 1
+}
+
+func DetectMounts(client *api.Client) ([]KVMount, error) {
+	mounts, err := client.Sys().ListMounts()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list mounts: %v", err)
+	}
+
+	var result []KVMount
+	for path, mount := range mounts {
+		if mount.Type != "kv" && mount.Type != "generic" {
+			continue
+		}
+
+		kvMount := KVMount{
+			Path:    strings.Trim(path, "/"),
+			Version: mountVersion(mount.Options),
+		}
+		registerMount(kvMount)
+		result = append(result, kvMount)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Path < result[j].Path
+	})
+
+	return result, nil
+}
+
+func cachedMount(path string) (KVMount, string, bool) {
+	mountsMu.RLock()
+	defer mountsMu.RUnlock()
+
+	var best KVMount
+	found := false
+	for mountPath, mount := range knownMounts {
+		if path != mountPath && !strings.HasPrefix(path, mountPath+"/") {
+			continue
+		}
+		if !found || len(mountPath) > len(best.Path) {
+			best = mount
+			found = true
+		}
+	}
+
+	if !found {
+		return KVMount{}, "", false
+	}
+
+	return best, strings.Trim(strings.TrimPrefix(path, best.Path), "/"), true
+}
+
+// sys/internal/ui/mounts responde para qualquer token com alguma permissão no caminho,
+// ao contrário de sys/mounts, que costuma exigir privilégios administrativos.
+func lookupMount(client *api.Client, path string) (KVMount, error) {
+	secret, err := client.Logical().Read("sys/internal/ui/mounts/" + path)
+	if err == nil && secret != nil && secret.Data != nil {
+		mountType, _ := secret.Data["type"].(string)
+		mountPath, _ := secret.Data["path"].(string)
+		if mountType != "kv" && mountType != "generic" {
+			return KVMount{}, fmt.Errorf("path '%s' is not on a KV mount (type: %s)", path, mountType)
+		}
+
+		options := make(map[string]string)
+		if raw, ok := secret.Data["options"].(map[string]interface{}); ok {
+			for key, value := range raw {
+				if str, ok := value.(string); ok {
+					options[key] = str
+				}
+			}
+		}
+
+		mount := KVMount{Path: strings.Trim(mountPath, "/"), Version: mountVersion(options)}
+		registerMount(mount)
+		return mount, nil
+	}
+
+	if _, listErr := DetectMounts(client); listErr != nil {
+		if err != nil {
+			return KVMount{}, fmt.Errorf("failed to detect mount for path '%s': %v", path, err)
+		}
+		return KVMount{}, fmt.Errorf("failed to detect mount for path '%s': %v", path, listErr)
+	}
+
+	if mount, _, ok := cachedMount(path); ok {
+		return mount, nil
+	}
+
+	return KVMount{}, fmt.Errorf("no KV mount found for path '%s'", path)
+}
+
+func ResolvePath(client *api.Client, path string) (KVPath, error) {
+	path = strings.Trim(normalizePathSlashes(path), "/")
+	if path == "" {
+		return KVPath{}, fmt.Errorf("empty path")
+	}
+
+	mount, rest, ok := cachedMount(path)
+	if !ok {
+		var err error
+		mount, err = lookupMount(client, path)
+		if err != nil {
+			return KVPath{}, err
+		}
+		rest = strings.Trim(strings.TrimPrefix(path, mount.Path), "/")
+	}
+
+	if mount.Version == 2 {
+		for _, prefix := range []string{"data", "metadata"} {
+			if rest == prefix {
+				rest = ""
+				break
+			}
+			if strings.HasPrefix(rest, prefix+"/") {
+				rest = strings.TrimPrefix(rest, prefix+"/")
+				break
+			}
+		}
+	}
+
+	return KVPath{Mount: mount, Secret: rest}, nil
+}
+
+// KVDataPath monta o caminho de dados sem consultar o Vault; mounts ainda não
+// detectados são tratados como KV v2.
+func KVDataPath(mount, secretPath string) string {
+	mount = strings.Trim(mount, "/")
+
+	mountsMu.RLock()
+	kvMount, ok := knownMounts[mount]
+	mountsMu.RUnlock()
+
+	if !ok {
+		kvMount = KVMount{Path: mount, Version: 2}
+	}
+
+	return KVPath{Mount: kvMount, Secret: secretPath}.DataPath()
+}
@@ -0,0 +1,87 @@
+package vault
+
+import (
+	"testing"
+
+	"github.com/hashicorp/vault/api"
+)
+
+// offlineClient aponta para um endereço sem Vault: só os mounts já registrados resolvem.
+func offlineClient(t *testing.T) *api.Client {
+	t.Helper()
+
+	registerMount(KVMount{Path: "kv-v1", Version: 1})
+	registerMount(KVMount{Path: "kv-v2", Version: 2})
+	registerMount(KVMount{Path: "team", Version: 2})
+	registerMount(KVMount{Path: "team/prd", Version: 1})
+
+	client, err := api.NewClient(&api.Config{Address: "http://127.0.0.1:1"})
+	if err != nil {
+		t.Fatalf("failed to create vault client: %v", err)
+	}
+	client.SetMaxRetries(0)
+	return client
+}
+
+func TestResolvePath(t *testing.T) {
+	client := offlineClient(t)
+
+	tests := []struct {
+		path         string
+		mount        string
+		secret       string
+		dataPath     string
+		metadataPath string
+	}{
+		{"kv-v2/app/config", "kv-v2", "app/config", "kv-v2/data/app/config", "kv-v2/metadata/app/config"},
+		{"kv-v2/data/app/config", "kv-v2", "app/config", "kv-v2/data/app/config", "kv-v2/metadata/app/config"},
+		{"/kv-v2/metadata/app/", "kv-v2", "app", "kv-v2/data/app", "kv-v2/metadata/app"},
+		{"kv-v2/data", "kv-v2", "", "kv-v2/data", "kv-v2/metadata"},
+		{"kv-v2", "kv-v2", "", "kv-v2/data", "kv-v2/metadata"},
+		{"kv-v1/data/app", "kv-v1", "data/app", "kv-v1/data/app", "kv-v1/data/app"},
+		{"kv-v2//app//config", "kv-v2", "app/config", "kv-v2/data/app/config", "kv-v2/metadata/app/config"},
+		// O mount mais específico vence
+		{"team/prd/app", "team/prd", "app", "team/prd/app", "team/prd/app"},
+		{"team/hml/app", "team", "hml/app", "team/data/hml/app", "team/metadata/hml/app"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			kvPath, err := ResolvePath(client, tt.path)
+			if err != nil {
+				t.Fatalf("ResolvePath: %v", err)
+			}
+			if kvPath.Mount.Path != tt.mount || kvPath.Secret != tt.secret {
+				t.Errorf("got mount %q secret %q, want %q %q", kvPath.Mount.Path, kvPath.Secret, tt.mount, tt.secret)
+			}
+			if got := kvPath.DataPath(); got != tt.dataPath {
+				t.Errorf("DataPath = %q, want %q", got, tt.dataPath)
+			}
+			if got := kvPath.MetadataPath(); got != tt.metadataPath {
+				t.Errorf("MetadataPath = %q, want %q", got, tt.metadataPath)
+			}
+		})
+	}
+}
+
+func TestResolvePathErrors(t *testing.T) {
+	client := offlineClient(t)
+
+	for _, path := range []string{"", "/", "unknown-mount/app"} {
+		if _, err := ResolvePath(client, path); err == nil {
+			t.Errorf("ResolvePath(%q) succeeded, want an error", path)
+		}
+	}
+}
+
+func TestKVPathChild(t *testing.T) {
+	parent := KVPath{Mount: KVMount{Path: "kv-v2", Version: 2}, Secret: "app"}
+
+	child := parent.Child("db/")
+	if child.Secret != "app/db" || child.ListPath() != "kv-v2/metadata/app/db/" {
+		t.Errorf("Child = %+v (list %q)", child, child.ListPath())
+	}
+	if got := parent.Child("config").String(); got != "kv-v2/app/config" {
+		t.Errorf("String = %q", got)
+	}
+}
@@ -0,0 +1,182 @@
+package vault
+
+import (
+	"context"
+	"crypto/rand"
+	"devops-go-vault-api/config"
+	"fmt"
+	"log"
+	"math/big"
+	"strings"
+
+	"github.com/hashicorp/vault/api"
+)
+
+const (
+	defaultPasswordLength = 24
+	minPasswordLength     = 12
+	maxPasswordLength     = 256
+
+	lowercaseChars = "abcdefghijklmnopqrstuvwxyz"
+	uppercaseChars = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	digitChars     = "0123456789"
+	symbolChars    = "!@#$%&*()-_=+[]{}:,.?"
+)
+
+// PasswordPolicy descreve como gerar uma senha. Name refere-se a uma password policy do
+// Vault; os demais campos configuram o gerador local, usado quando não há Name.
+type PasswordPolicy struct {
+	Name      string `json:"name,omitempty"`
+	Length    int    `json:"length,omitempty"`
+	Lowercase *bool  `json:"lowercase,omitempty"`
+	Uppercase *bool  `json:"uppercase,omitempty"`
+	Digits    *bool  `json:"digits,omitempty"`
+	Symbols   *bool  `json:"symbols,omitempty"`
+	Exclude   string `json:"exclude,omitempty"`
+}
+
+type PasswordSource string
+
+const (
+	VaultPolicySource    PasswordSource = "vault_policy"
+	LocalGeneratorSource PasswordSource = "local"
+)
+
+// GeneratePassword usa a password policy do Vault quando informada (ou VAULT_PASSWORD_POLICY).
+// Se a policy foi pedida explicitamente em Name, uma falha do Vault é devolvida; só a
+// policy padrão de VAULT_PASSWORD_POLICY recorre ao gerador local.
+func GeneratePassword(ctx context.Context, client *api.Client, policy PasswordPolicy) (string, PasswordSource, error) {
+	charsets, err := policy.charsets()
+	if err != nil {
+		return "", "", err
+	}
+
+	name := policy.Name
+	if name == "" {
+		name = config.VaultPasswordPolicy
+	}
+
+	if name != "" {
+		password, err := generateFromVaultPolicy(ctx, client, name)
+		if err == nil {
+			return password, VaultPolicySource, nil
+		}
+		if policy.Name != "" {
+			return "", "", fmt.Errorf("failed to generate password with policy '%s': %v", name, err)
+		}
+		log.Printf("Falha ao gerar senha pela policy '%s' do Vault, usando gerador local: %v", name, err)
+	}
+
+	password, err := generateLocalPassword(policy.length(), charsets)
+	if err != nil {
+		return "", "", err
+	}
+	return password, LocalGeneratorSource, nil
+}
+
+func generateFromVaultPolicy(ctx context.Context, client *api.Client, name string) (string, error) {
+	secret, err := client.Logical().ReadWithContext(ctx, fmt.Sprintf("sys/policies/password/%s/generate", name))
+	if err != nil {
+		return "", err
+	}
+	if secret == nil || secret.Data == nil {
+		return "", fmt.Errorf("password policy '%s' not found", name)
+	}
+
+	password, ok := secret.Data["password"].(string)
+	if !ok || password == "" {
+		return "", fmt.Errorf("password policy '%s' returned no password", name)
+	}
+	return password, nil
+}
+
+func (p PasswordPolicy) length() int {
+	if p.Length == 0 {
+		return defaultPasswordLength
+	}
+	return p.Length
+}
+
+// charsets devolve as classes de caracteres habilitadas (todas por padrão), já sem os excluídos.
+func (p PasswordPolicy) charsets() ([]string, error) {
+	if p.Length != 0 && (p.Length < minPasswordLength || p.Length > maxPasswordLength) {
+		return nil, fmt.Errorf("password length must be between %d and %d", minPasswordLength, maxPasswordLength)
+	}
+
+	classes := []struct {
+		enabled *bool
+		chars   string
+	}{
+		{p.Lowercase, lowercaseChars},
+		{p.Uppercase, uppercaseChars},
+		{p.Digits, digitChars},
+		{p.Symbols, symbolChars},
+	}
+
+	var charsets []string
+	for _, class := range classes {
+		if class.enabled != nil && !*class.enabled {
+			continue
+		}
+
+		chars := strings.Map(func(r rune) rune {
+			if strings.ContainsRune(p.Exclude, r) {
+				return -1
+			}
+			return r
+		}, class.chars)
+
+		if chars == "" {
+			return nil, fmt.Errorf("exclude removes every character of an enabled class")
+		}
+		charsets = append(charsets, chars)
+	}
+
+	if len(charsets) == 0 {
+		return nil, fmt.Errorf("at least one character class must be enabled")
+	}
+	return charsets, nil
+}
+
+// generateLocalPassword garante ao menos um caractere de cada classe e embaralha o resultado.
+func generateLocalPassword(length int, charsets []string) (string, error) {
+	if length < len(charsets) {
+		return "", fmt.Errorf("password length %d is too short for %d character classes", length, len(charsets))
+	}
+
+	password := make([]byte, 0, length)
+	for _, chars := range charsets {
+		c, err := randomChar(chars)
+		if err != nil {
+			return "", err
+		}
+		password = append(password, c)
+	}
+
+	all := strings.Join(charsets, "")
+	for len(password) < length {
+		c, err := randomChar(all)
+		if err != nil {
+			return "", err
+		}
+		password = append(password, c)
+	}
+
+	for i := len(password) - 1; i > 0; i-- {
+		j, err := rand.Int(rand.Reader, big.NewInt(int64(i+1)))
+		if err != nil {
+			return "", err
+		}
+		password[i], password[j.Int64()] = password[j.Int64()], password[i]
+	}
+
+	return string(password), nil
+}
+
+func randomChar(chars string) (byte, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(int64(len(chars))))
+	if err != nil {
+		return 0, err
+	}
+	return chars[n.Int64()], nil
+}
@@ -1,5 +1,11 @@
 package vault
 
-func SearchAndReplacePassword(basePath, oldPassword, newPassword string) ([]PasswordUpdateResult, error) {
-	return SearchAndReplacePasswordDirect(basePath, oldPassword, newPassword, EditMode)
+import (
+	"context"
+
+	"github.com/hashicorp/vault/api"
+)
+
+func SearchAndReplacePassword(ctx context.Context, client *api.Client, basePath, oldPassword, newPassword string) ([]PasswordUpdateResult, error) {
+	return SearchAndReplacePasswordDirect(ctx, client, basePath, oldPassword, newPassword, EditMode, LogObserver{Operation: "updatePassword"})
 }
@@ -0,0 +1,323 @@
+package vault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/vault/api"
+)
+
+type ComparisonStatus string
+
+const (
+	OnlyInSource ComparisonStatus = "only_in_source"
+	OnlyInTarget ComparisonStatus = "only_in_target"
+	Different    ComparisonStatus = "different"
+	Identical    ComparisonStatus = "identical"
+	// ComparisonError indica que o caminho não pôde ser lido em um dos lados
+	ComparisonError ComparisonStatus = "error"
+)
+
+var ErrOverlappingPrefixes = errors.New("source and target overlap")
+
+// PathComparison compara o mesmo caminho relativo nos dois prefixos. Em Changes,
+// "added" é uma chave que falta no destino e "removed" uma chave que só existe nele.
+type PathComparison struct {
+	Path       string           `json:"path"`
+	SourcePath string           `json:"source_path,omitempty"`
+	TargetPath string           `json:"target_path,omitempty"`
+	Status     ComparisonStatus `json:"status"`
+	Changes    []KeyDiff        `json:"changes,omitempty"`
+	Error      string           `json:"error,omitempty"`
+}
+
+type prefixSnapshot struct {
+	root    KVPath
+	secrets map[string]map[string]interface{}
+	// failures guarda o erro de cada segredo ilegível e, com "/" no fim, de cada
+	// diretório que não pôde ser listado
+	failures map[string]ScanFailure
+}
+
+// resolvePrefixes resolve source e target e recusa prefixos em que um contém o outro.
+func resolvePrefixes(client *api.Client, source, target string) (KVPath, KVPath, error) {
+	src, err := ResolvePath(client, source)
+	if err != nil {
+		return KVPath{}, KVPath{}, fmt.Errorf("failed to resolve source: %w", err)
+	}
+	dst, err := ResolvePath(client, target)
+	if err != nil {
+		return KVPath{}, KVPath{}, fmt.Errorf("failed to resolve target: %w", err)
+	}
+
+	if src.Mount.Path == dst.Mount.Path && (isPathPrefix(src.Secret, dst.Secret) || isPathPrefix(dst.Secret, src.Secret)) {
+		return KVPath{}, KVPath{}, fmt.Errorf("%w: '%s' and '%s'", ErrOverlappingPrefixes, src, dst)
+	}
+	return src, dst, nil
+}
+
+func isPathPrefix(prefix, secret string) bool {
+	return prefix == "" || secret == prefix || strings.HasPrefix(secret, prefix+"/")
+}
+
+// readPrefix lê todos os segredos abaixo de root, indexados pelo caminho relativo a ele.
+func readPrefix(ctx context.Context, client *api.Client, root KVPath, filter PathFilter, observer Observer) (*prefixSnapshot, error) {
+	snapshot := &prefixSnapshot{
+		root:     root,
+		secrets:  make(map[string]map[string]interface{}),
+		failures: make(map[string]ScanFailure),
+	}
+	var mu sync.Mutex
+
+	failures, err := scanTree(ctx, client, root.String(), filter, observer, func(_ context.Context, secret KVPath, data map[string]interface{}) {
+		rel := snapshot.relOf(secret)
+
+		mu.Lock()
+		defer mu.Unlock()
+		snapshot.secrets[rel] = data
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, failure := range failures {
+		rel := snapshot.relOf(failure.secret)
+		if failure.isDir {
+			rel += "/"
+		}
+		snapshot.failures[rel] = failure
+	}
+	return snapshot, nil
+}
+
+func (s *prefixSnapshot) relOf(secret KVPath) string {
+	return strings.Trim(strings.TrimPrefix(secret.Secret, s.root.Secret), "/")
+}
+
+func (s *prefixSnapshot) pathOf(rel string) KVPath {
+	return KVPath{Mount: s.root.Mount, Secret: joinPath(s.root.Secret, rel)}
+}
+
+// failureFor devolve a falha que impediu ler rel: a do próprio segredo ou a de um
+// diretório acima dele.
+func (s *prefixSnapshot) failureFor(rel string) (ScanFailure, bool) {
+	if failure, ok := s.failures[rel]; ok && !failure.isDir {
+		return failure, true
+	}
+	for dir, failure := range s.failures {
+		if failure.isDir && (dir == "/" || strings.HasPrefix(rel+"/", dir)) {
+			return failure, true
+		}
+	}
+	return ScanFailure{}, false
+}
+
+// ComparePrefixes compara source e target (no mesmo mount ou em mounts diferentes)
+// caminho a caminho, sem expor os valores. Caminhos idênticos só entram com includeIdentical.
+// Caminhos que não puderam ser lidos em algum dos lados voltam com status error.
+func ComparePrefixes(ctx context.Context, client *api.Client, source, target string, filter PathFilter, includeIdentical bool, observer Observer) ([]PathComparison, error) {
+	srcRoot, dstRoot, err := resolvePrefixes(client, source, target)
+	if err != nil {
+		return nil, err
+	}
+
+	src, err := readPrefix(ctx, client, srcRoot, filter, observer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read source: %w", err)
+	}
+	dst, err := readPrefix(ctx, client, dstRoot, filter, observer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read target: %w", err)
+	}
+
+	paths := make(map[string]bool)
+	for _, snapshot := range []*prefixSnapshot{src, dst} {
+		for rel := range snapshot.secrets {
+			paths[rel] = true
+		}
+		for rel, failure := range snapshot.failures {
+			if !failure.isDir {
+				paths[rel] = true
+			}
+		}
+	}
+
+	comparisons := []PathComparison{}
+	for rel := range paths {
+		srcData, inSource := src.secrets[rel]
+		dstData, inTarget := dst.secrets[rel]
+
+		comparison := PathComparison{Path: rel}
+		if inSource {
+			comparison.SourcePath = src.pathOf(rel).DataPath()
+		}
+		if inTarget {
+			comparison.TargetPath = dst.pathOf(rel).DataPath()
+		}
+
+		srcFailure, srcFailed := src.failureFor(rel)
+		dstFailure, dstFailed := dst.failureFor(rel)
+
+		switch {
+		case srcFailed:
+			comparison.SourcePath = src.pathOf(rel).DataPath()
+			comparison.Status = ComparisonError
+			comparison.Error = fmt.Sprintf("source: %s", srcFailure.Error)
+		case dstFailed:
+			comparison.TargetPath = dst.pathOf(rel).DataPath()
+			comparison.Status = ComparisonError
+			comparison.Error = fmt.Sprintf("target: %s", dstFailure.Error)
+		case !inTarget:
+			comparison.Status = OnlyInSource
+		case !inSource:
+			comparison.Status = OnlyInTarget
+		default:
+			comparison.Changes = DiffData(dstData, srcData, false, false)
+			comparison.Status = Different
+			if len(comparison.Changes) == 0 {
+				comparison.Status = Identical
+			}
+		}
+
+		if comparison.Status == Identical && !includeIdentical {
+			continue
+		}
+		comparisons = append(comparisons, comparison)
+	}
+
+	sort.Slice(comparisons, func(i, j int) bool {
+		return comparisons[i].Path < comparisons[j].Path
+	})
+	return comparisons, nil
+}
+
+// PromoteOptions copia apenas as chaves em Keys; Paths, se informado, restringe os
+// caminhos relativos promovidos.
+type PromoteOptions struct {
+	Source string
+	Target string
+	Keys   []string
+	Paths  []string
+	Filter PathFilter
+	DryRun bool
+}
+
+type PromotionResult struct {
+	Path      string   `json:"path"`
+	Target    string   `json:"target"`
+	Copied    []string `json:"copied,omitempty"`
+	Unchanged []string `json:"unchanged,omitempty"`
+	Error     string   `json:"error,omitempty"`
+}
+
+// PromoteKeys copia do source para o target as chaves permitidas que estão ausentes ou
+// diferentes no destino, mesclando-as com as chaves que o destino já tem.
+func PromoteKeys(ctx context.Context, client *api.Client, opts PromoteOptions, observer Observer) ([]PromotionResult, error) {
+	if len(opts.Keys) == 0 {
+		return nil, fmt.Errorf("keys is required: promotion only copies allow-listed keys")
+	}
+	ctx = WithRateLimit(ctx)
+
+	srcRoot, dst, err := resolvePrefixes(client, opts.Source, opts.Target)
+	if err != nil {
+		return nil, err
+	}
+
+	src, err := readPrefix(ctx, client, srcRoot, opts.Filter, observer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read source: %w", err)
+	}
+	target := &prefixSnapshot{root: dst}
+
+	allowedPaths := make(map[string]bool)
+	for _, rel := range opts.Paths {
+		allowedPaths[strings.Trim(rel, "/")] = true
+	}
+
+	var rels []string
+	for rel := range src.secrets {
+		if len(allowedPaths) == 0 || allowedPaths[rel] {
+			rels = append(rels, rel)
+		}
+	}
+	sort.Strings(rels)
+
+	// Segredos do source que não puderam ser lidos não são promovidos, mas aparecem no resultado
+	results := []PromotionResult{}
+	for rel, failure := range src.failures {
+		if failure.isDir || len(allowedPaths) == 0 || allowedPaths[rel] {
+			results = append(results, PromotionResult{Path: rel, Target: target.pathOf(rel).DataPath(), Error: fmt.Sprintf("source: %s", failure.Error)})
+		}
+	}
+
+	for _, rel := range rels {
+		if ctx.Err() != nil {
+			return results, ctx.Err()
+		}
+
+		srcData := src.secrets[rel]
+		targetPath := target.pathOf(rel)
+		result := PromotionResult{Path: rel, Target: targetPath.DataPath()}
+
+		current, _, err := readSecretDataWithContext(ctx, client, targetPath)
+		if err != nil {
+			result.Error = err.Error()
+			results = append(results, result)
+			continue
+		}
+
+		toCopy := make(map[string]string)
+		for _, key := range opts.Keys {
+			value, ok := srcData[key]
+			if !ok {
+				continue
+			}
+
+			strValue, ok := value.(string)
+			if !ok {
+				result.Error = fmt.Sprintf("key '%s' is not a string and was not copied", key)
+				continue
+			}
+
+			if existing, ok := current[key]; ok && fmt.Sprint(existing) == strValue {
+				result.Unchanged = append(result.Unchanged, key)
+				continue
+			}
+			toCopy[key] = strValue
+			result.Copied = append(result.Copied, key)
+		}
+
+		if len(result.Copied) == 0 && len(result.Unchanged) == 0 && result.Error == "" {
+			continue
+		}
+
+		// Caminhos protegidos são recusados também no dry-run, que não deve prometer a cópia
+		if len(toCopy) > 0 {
+			if err := checkProtected(targetPath); err != nil {
+				result.Error = err.Error()
+				result.Copied = nil
+			}
+		}
+
+		if len(result.Copied) > 0 && !opts.DryRun {
+			if err := StoreInVaultWithContext(ctx, client, targetPath.DataPath(), toCopy, StoreOptions{Mode: MergeWrite}); err != nil {
+				result.Error = err.Error()
+				result.Copied = nil
+				emit(observer, Event{Type: EventWriteFailed, Path: result.Target, Count: len(toCopy), Error: err.Error()})
+			} else {
+				emit(observer, Event{Type: EventWriteOK, Path: result.Target, Count: len(toCopy)})
+			}
+		}
+
+		results = append(results, result)
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Path < results[j].Path
+	})
+	return results, nil
+}
@@ -0,0 +1,53 @@
+package vault
+
+import (
+	"context"
+	"devops-go-vault-api/internal/vault/vaulttest"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestResolvePrefixesRejectsOverlap(t *testing.T) {
+	client := offlineClient(t)
+
+	tests := []struct {
+		source, target string
+		overlap        bool
+	}{
+		{"kv-v2/app", "kv-v2/data/app/prd", true},
+		{"kv-v2/app/prd", "kv-v2/app", true},
+		{"kv-v2/app", "kv-v2/app", true},
+		{"kv-v2", "kv-v2/app", true},
+		{"kv-v2/app", "kv-v2/app2", false},
+		{"kv-v2/hml/app", "kv-v2/prd/app", false},
+		{"kv-v2/app", "kv-v1/app", false},
+	}
+
+	for _, tt := range tests {
+		_, _, err := resolvePrefixes(client, tt.source, tt.target)
+		if errors.Is(err, ErrOverlappingPrefixes) != tt.overlap {
+			t.Errorf("resolvePrefixes(%q, %q) = %v, want overlap %v", tt.source, tt.target, err, tt.overlap)
+		}
+	}
+}
+
+func TestPromoteDryRunReportsProtectedTarget(t *testing.T) {
+	srv := vaulttest.NewServer(t)
+	srv.Put("hml/app/config", map[string]interface{}{"FEATURE_X": "on"})
+	withProtectedPaths(t, vaulttest.Mount+"/prd")
+
+	results, err := PromoteKeys(context.Background(), srv.Client(t), PromoteOptions{
+		Source: vaulttest.Mount + "/hml/app",
+		Target: vaulttest.Mount + "/prd/app",
+		Keys:   []string{"FEATURE_X"},
+		DryRun: true,
+	}, nil)
+	if err != nil {
+		t.Fatalf("PromoteKeys: %v", err)
+	}
+
+	if len(results) != 1 || len(results[0].Copied) != 0 || !strings.Contains(results[0].Error, ErrProtectedPath.Error()) {
+		t.Errorf("results = %+v, want the protected target reported as an error", results)
+	}
+}
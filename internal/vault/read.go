@@ -0,0 +1,158 @@
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+)
+
+var ErrSecretNotFound = api.ErrSecretNotFound
+
+type SecretMetadata struct {
+	Version        int                    `json:"version"`
+	CurrentVersion int                    `json:"current_version"`
+	OldestVersion  int                    `json:"oldest_version"`
+	CreatedTime    time.Time              `json:"created_time"`
+	UpdatedTime    time.Time              `json:"updated_time"`
+	DeletionTime   *time.Time             `json:"deletion_time,omitempty"`
+	Deleted        bool                   `json:"deleted"`
+	Destroyed      bool                   `json:"destroyed"`
+	CustomMetadata map[string]interface{} `json:"custom_metadata,omitempty"`
+}
+
+type SecretView struct {
+	Path      string                 `json:"path"`
+	Mount     string                 `json:"mount"`
+	KVVersion int                    `json:"kv_version"`
+	Data      map[string]interface{} `json:"data"`
+	Metadata  *SecretMetadata        `json:"metadata,omitempty"`
+}
+
+// ReadSecret lê o segredo em path; version 0 significa a versão atual e só é aceito
+// um valor diferente de zero em mounts KV v2.
+func ReadSecret(client *api.Client, path string, version int) (*SecretView, error) {
+	kvPath, err := ResolvePath(client, path)
+	if err != nil {
+		return nil, err
+	}
+
+	if kvPath.Secret == "" {
+		return nil, fmt.Errorf("path '%s' points to a mount, not a secret", path)
+	}
+
+	view := &SecretView{
+		Path:      kvPath.DataPath(),
+		Mount:     kvPath.Mount.Path,
+		KVVersion: kvPath.Mount.Version,
+	}
+
+	ctx := context.Background()
+
+	if kvPath.Mount.Version != 2 {
+		if version != 0 {
+			return nil, fmt.Errorf("mount '%s' is KV v1 and does not support versions", kvPath.Mount.Path)
+		}
+
+		secret, err := client.KVv1(kvPath.Mount.Path).Get(ctx, kvPath.Secret)
+		if err != nil {
+			return nil, err
+		}
+
+		view.Data = secret.Data
+		return view, nil
+	}
+
+	kv := client.KVv2(kvPath.Mount.Path)
+
+	var secret *api.KVSecret
+	if version > 0 {
+		secret, err = kv.GetVersion(ctx, kvPath.Secret, version)
+	} else {
+		secret, err = kv.Get(ctx, kvPath.Secret)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	metadata, err := kv.GetMetadata(ctx, kvPath.Secret)
+	if err != nil && !errors.Is(err, api.ErrSecretNotFound) {
+		return nil, fmt.Errorf("failed to read metadata at path '%s': %v", path, err)
+	}
+
+	view.Data = secret.Data
+	view.Metadata = &SecretMetadata{
+		CustomMetadata: secret.CustomMetadata,
+	}
+
+	if secret.VersionMetadata != nil {
+		view.Metadata.Version = secret.VersionMetadata.Version
+		view.Metadata.CreatedTime = secret.VersionMetadata.CreatedTime
+		view.Metadata.Destroyed = secret.VersionMetadata.Destroyed
+		if !secret.VersionMetadata.DeletionTime.IsZero() {
+			deletionTime := secret.VersionMetadata.DeletionTime
+			view.Metadata.DeletionTime = &deletionTime
+			view.Metadata.Deleted = deletionTime.Before(time.Now())
+		}
+	}
+
+	if metadata != nil {
+		view.Metadata.CurrentVersion = metadata.CurrentVersion
+		view.Metadata.OldestVersion = metadata.OldestVersion
+		view.Metadata.UpdatedTime = metadata.UpdatedTime
+		if metadata.CustomMetadata != nil {
+			view.Metadata.CustomMetadata = metadata.CustomMetadata
+		}
+	}
+
+	return view, nil
+}
+
+// readSecretData devolve os dados atuais e a versão (0 em KV v1). Segredos inexistentes
+// retornam dados nil sem erro; se a versão atual foi deletada, a versão ainda é retornada.
+func readSecretData(client *api.Client, kvPath KVPath) (map[string]interface{}, int, error) {
+	return readSecretDataWithContext(context.Background(), client, kvPath)
+}
+
+func readSecretDataWithContext(ctx context.Context, client *api.Client, kvPath KVPath) (map[string]interface{}, int, error) {
+	if err := waitLimit(ctx); err != nil {
+		return nil, 0, err
+	}
+
+	secret, err := client.Logical().ReadWithContext(ctx, kvPath.DataPath())
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if secret == nil || secret.Data == nil {
+		return nil, 0, nil
+	}
+
+	if kvPath.Mount.Version != 2 {
+		return secret.Data, 0, nil
+	}
+
+	version := 0
+	if metadata, ok := secret.Data["metadata"].(map[string]interface{}); ok {
+		version = intValue(metadata["version"])
+	}
+
+	data, _ := secret.Data["data"].(map[string]interface{})
+	return data, version, nil
+}
+
+func intValue(value interface{}) int {
+	switch v := value.(type) {
+	case json.Number:
+		n, _ := v.Int64()
+		return int(n)
+	case float64:
+		return int(v)
+	case int:
+		return v
+	}
+	return 0
+}
@@ -0,0 +1,111 @@
+package vault
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/hashicorp/vault/api"
+)
+
+type DeleteResult struct {
+	Path  string `json:"path"`
+	Error string `json:"error,omitempty"`
+}
+
+// PlanRecursiveDelete lista todos os segredos abaixo de path (incluindo o próprio
+// path, se for um segredo) sem apagar nada. Falha se algum deles estiver protegido ou
+// se mode não puder ser executado no mount.
+func PlanRecursiveDelete(ctx context.Context, client *api.Client, path string, mode DeleteMode) ([]string, error) {
+	root, err := ResolvePath(client, path)
+	if err != nil {
+		return nil, err
+	}
+
+	if root.Mount.Version != 2 && mode != MetadataDelete {
+		return nil, fmt.Errorf("%w: mount '%s'", ErrKVv1DeleteMode, root.Mount.Path)
+	}
+
+	if err := checkProtected(root); err != nil {
+		return nil, err
+	}
+
+	paths := []string{}
+
+	if root.Secret != "" {
+		data, version, err := readSecretData(client, root)
+		if err != nil {
+			return nil, err
+		}
+		if data != nil || version > 0 {
+			paths = append(paths, root.DataPath())
+		}
+	}
+
+	var mu sync.Mutex
+	var listErr error
+
+	// Um diretório que não pôde ser listado deixaria o plano incompleto
+	walker := NewWalker(client)
+	walker.OnListError = func(dir KVPath, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if listErr == nil {
+			listErr = err
+		}
+	}
+
+	err = walker.Walk(ctx, root, func(ctx context.Context, secret KVPath) error {
+		if err := checkProtected(secret); err != nil {
+			return err
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		paths = append(paths, secret.DataPath())
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if listErr != nil {
+		return nil, listErr
+	}
+
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// DeleteSecrets executa um plano já emitido. Os caminhos são conferidos de novo contra
+// VAULT_PROTECTED_PATHS antes de qualquer deleção, já que a configuração pode ter mudado
+// desde o plano; se algum estiver protegido, nada é apagado. Se ctx for cancelado, os
+// caminhos restantes não são apagados e voltam com o erro do cancelamento.
+func DeleteSecrets(ctx context.Context, client *api.Client, paths []string, opts DeleteOptions) ([]DeleteResult, error) {
+	ctx = WithRateLimit(ctx)
+
+	for _, path := range paths {
+		kvPath, err := ResolvePath(client, path)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkProtected(kvPath); err != nil {
+			return nil, err
+		}
+	}
+
+	results := make([]DeleteResult, len(paths))
+
+	for i, path := range paths {
+		results[i].Path = path
+		if err := ctx.Err(); err != nil {
+			results[i].Error = err.Error()
+			continue
+		}
+		if err := deleteSecret(ctx, client, path, opts); err != nil {
+			results[i].Error = err.Error()
+		}
+	}
+
+	return results, nil
+}
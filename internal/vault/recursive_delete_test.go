@@ -0,0 +1,42 @@
+package vault
+
+import (
+	"context"
+	"devops-go-vault-api/internal/vault/vaulttest"
+	"errors"
+	"testing"
+)
+
+func TestPlanRecursiveDeleteRejectsKVv1Modes(t *testing.T) {
+	client := offlineClient(t)
+
+	for _, mode := range []DeleteMode{SoftDelete, UndeleteMode, DestroyDelete} {
+		if _, err := PlanRecursiveDelete(context.Background(), client, "kv-v1/app", mode); !errors.Is(err, ErrKVv1DeleteMode) {
+			t.Errorf("mode %s: err = %v, want ErrKVv1DeleteMode", mode, err)
+		}
+	}
+}
+
+func TestDeleteSecretsStopsWhenCanceled(t *testing.T) {
+	srv := vaulttest.NewServer(t)
+	srv.Put("app/a", map[string]interface{}{"KEY": "a"})
+	srv.Put("app/b", map[string]interface{}{"KEY": "b"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	paths := []string{vaulttest.Mount + "/data/app/a", vaulttest.Mount + "/data/app/b"}
+	results, err := DeleteSecrets(ctx, srv.Client(t), paths, DeleteOptions{Mode: MetadataDelete})
+	if err != nil {
+		t.Fatalf("DeleteSecrets: %v", err)
+	}
+
+	for _, result := range results {
+		if result.Error == "" {
+			t.Errorf("%s was deleted after cancellation", result.Path)
+		}
+	}
+	if srv.Version("app/a") == 0 || srv.Version("app/b") == 0 {
+		t.Error("secrets were deleted after cancellation")
+	}
+}
@@ -0,0 +1,201 @@
+package vault
+
+import (
+	"context"
+	"crypto/rand"
+	"devops-go-vault-api/config"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/vault/api"
+)
+
+const defaultReuseMinLength = 8
+
+type ReuseOptions struct {
+	BasePath string
+	Filter   PathFilter
+
+	// KeyGlob restringe as chaves consideradas (ex: *PASSWORD*); vazio considera todas.
+	KeyGlob string
+
+	// MinLength ignora valores curtos como portas e flags (padrão: 8).
+	MinLength int
+
+	// AppSegment é a posição (a partir de 1, contada desde o mount) do segmento do
+	// caminho que identifica a aplicação; 0 usa o último segmento, como em
+	// general/dba/<sgbd>/<host>/<app>.
+	AppSegment int
+}
+
+type ReuseOccurrence struct {
+	Path        string `json:"path"`
+	Key         string `json:"key"`
+	Application string `json:"application"`
+}
+
+// ReuseCluster agrupa as chaves que guardam o mesmo valor. Fingerprint é o HMAC-SHA256
+// do valor; com VAULT_API_HMAC_KEY configurada ele pode ser usado em old_password_hmac.
+type ReuseCluster struct {
+	Fingerprint  string            `json:"fingerprint"`
+	Count        int               `json:"count"`
+	Applications []string          `json:"applications"`
+	Occurrences  []ReuseOccurrence `json:"occurrences"`
+}
+
+type ApplicationReuse struct {
+	Application string `json:"application"`
+	SharedKeys  int    `json:"shared_keys"`
+	Clusters    int    `json:"clusters"`
+}
+
+type ReuseReport struct {
+	BasePath           string             `json:"base_path"`
+	Scanned            int                `json:"scanned"`
+	StableFingerprints bool               `json:"stable_fingerprints"`
+	Clusters           []ReuseCluster     `json:"clusters"`
+	Applications       []ApplicationReuse `json:"applications"`
+	Failures           []ScanFailure      `json:"failures,omitempty"`
+}
+
+// FindReusedValues agrupa os valores repetidos abaixo de BasePath. Os valores só existem
+// em memória durante a leitura de cada segredo; o relatório guarda apenas o HMAC.
+func FindReusedValues(ctx context.Context, client *api.Client, opts ReuseOptions, observer Observer) (*ReuseReport, error) {
+	keys := Matcher(MatcherFunc(func(key, value string) bool { return true }))
+	if opts.KeyGlob != "" {
+		matcher, err := NewMatcher(SearchCriteria{KeyGlob: opts.KeyGlob})
+		if err != nil {
+			return nil, err
+		}
+		keys = matcher
+	}
+
+	if opts.MinLength == 0 {
+		opts.MinLength = defaultReuseMinLength
+	}
+
+	if opts.AppSegment < 0 {
+		return nil, fmt.Errorf("app_segment must not be negative")
+	}
+
+	// Sem chave configurada, uma chave aleatória por relatório ainda agrupa os valores,
+	// mas as fingerprints não se repetem entre relatórios
+	hmacKey := []byte(config.VaultAPIHMACKey)
+	report := &ReuseReport{BasePath: opts.BasePath, StableFingerprints: len(hmacKey) > 0}
+	if len(hmacKey) == 0 {
+		hmacKey = make([]byte, 32)
+		if _, err := rand.Read(hmacKey); err != nil {
+			return nil, err
+		}
+	}
+
+	var mu sync.Mutex
+	groups := make(map[string][]ReuseOccurrence)
+
+	failures, err := scanTree(ctx, client, opts.BasePath, opts.Filter, observer, func(_ context.Context, secret KVPath, data map[string]interface{}) {
+		var found []ReuseOccurrence
+		var fingerprints []string
+
+		for key, value := range data {
+			strValue, ok := value.(string)
+			if !ok || len(strValue) < opts.MinLength || !keys.Match(key, strValue) {
+				continue
+			}
+
+			fingerprints = append(fingerprints, hex.EncodeToString(ValueHMAC(hmacKey, strValue)))
+			found = append(found, ReuseOccurrence{
+				Path:        secret.DataPath(),
+				Key:         key,
+				Application: applicationOf(secret, opts.AppSegment),
+			})
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		report.Scanned++
+		for i, fingerprint := range fingerprints {
+			groups[fingerprint] = append(groups[fingerprint], found[i])
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	report.Clusters, report.Applications = summarizeReuse(groups)
+	report.Failures = failures
+	return report, nil
+}
+
+func summarizeReuse(groups map[string][]ReuseOccurrence) ([]ReuseCluster, []ApplicationReuse) {
+	clusters := []ReuseCluster{}
+	perApp := make(map[string]*ApplicationReuse)
+
+	for fingerprint, occurrences := range groups {
+		if len(occurrences) < 2 {
+			continue
+		}
+
+		sort.Slice(occurrences, func(i, j int) bool {
+			if occurrences[i].Path != occurrences[j].Path {
+				return occurrences[i].Path < occurrences[j].Path
+			}
+			return occurrences[i].Key < occurrences[j].Key
+		})
+
+		var apps []string
+		seen := make(map[string]bool)
+		for _, occurrence := range occurrences {
+			app := perApp[occurrence.Application]
+			if app == nil {
+				app = &ApplicationReuse{Application: occurrence.Application}
+				perApp[occurrence.Application] = app
+			}
+			app.SharedKeys++
+
+			if !seen[occurrence.Application] {
+				seen[occurrence.Application] = true
+				apps = append(apps, occurrence.Application)
+				app.Clusters++
+			}
+		}
+		sort.Strings(apps)
+
+		clusters = append(clusters, ReuseCluster{
+			Fingerprint:  fingerprint,
+			Count:        len(occurrences),
+			Applications: apps,
+			Occurrences:  occurrences,
+		})
+	}
+
+	sort.Slice(clusters, func(i, j int) bool {
+		if clusters[i].Count != clusters[j].Count {
+			return clusters[i].Count > clusters[j].Count
+		}
+		return clusters[i].Fingerprint < clusters[j].Fingerprint
+	})
+
+	applications := []ApplicationReuse{}
+	for _, app := range perApp {
+		applications = append(applications, *app)
+	}
+	sort.Slice(applications, func(i, j int) bool {
+		if applications[i].SharedKeys != applications[j].SharedKeys {
+			return applications[i].SharedKeys > applications[j].SharedKeys
+		}
+		return applications[i].Application < applications[j].Application
+	})
+
+	return clusters, applications
+}
+
+func applicationOf(secret KVPath, segment int) string {
+	segments := strings.Split(secret.Secret, "/")
+	if segment == 0 || segment > len(segments) {
+		return segments[len(segments)-1]
+	}
+	return segments[segment-1]
+}
@@ -0,0 +1,217 @@
+package vault
+
+import (
+	"context"
+	"devops-go-vault-api/config"
+	"devops-go-vault-api/internal/vault/vaulttest"
+	"encoding/hex"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func newReuseVault(t *testing.T) *vaulttest.Server {
+	srv := vaulttest.NewServer(t)
+	srv.Put("general/dba/postgres/db01/app-a", map[string]interface{}{"POSTGRES_PASSWORD": "senha-compartilhada", "POSTGRES_PORT": "5432"})
+	srv.Put("general/dba/postgres/db02/app-b", map[string]interface{}{"POSTGRES_PASSWORD": "senha-compartilhada"})
+	srv.Put("app-c/config", map[string]interface{}{"DB_PASSWORD": "senha-compartilhada", "API_TOKEN": "token-repetido-123"})
+	srv.Put("app-d/config", map[string]interface{}{"API_TOKEN": "token-repetido-123", "PORT": "5432"})
+	srv.Put("app-e/config", map[string]interface{}{"DB_PASSWORD": "senha-exclusiva-e"})
+	return srv
+}
+
+func clusterOccurrences(report *ReuseReport) [][]string {
+	var clusters [][]string
+	for _, cluster := range report.Clusters {
+		var occurrences []string
+		for _, occurrence := range cluster.Occurrences {
+			occurrences = append(occurrences, strings.TrimPrefix(occurrence.Path, vaulttest.Mount+"/data/")+"#"+occurrence.Key)
+		}
+		clusters = append(clusters, occurrences)
+	}
+	return clusters
+}
+
+func TestFindReusedValuesClustersEqualValues(t *testing.T) {
+	srv := newReuseVault(t)
+
+	report, err := FindReusedValues(context.Background(), srv.Client(t), ReuseOptions{BasePath: vaulttest.Mount}, nil)
+	if err != nil {
+		t.Fatalf("FindReusedValues: %v", err)
+	}
+
+	// Valores únicos e curtos (as portas, abaixo do mínimo de 8) não formam grupos
+	want := [][]string{
+		{"app-c/config#DB_PASSWORD", "general/dba/postgres/db01/app-a#POSTGRES_PASSWORD", "general/dba/postgres/db02/app-b#POSTGRES_PASSWORD"},
+		{"app-c/config#API_TOKEN", "app-d/config#API_TOKEN"},
+	}
+	if got := clusterOccurrences(report); !reflect.DeepEqual(got, want) {
+		t.Errorf("clusters = %v, want %v", got, want)
+	}
+	if report.Scanned != 5 {
+		t.Errorf("scanned = %d, want 5", report.Scanned)
+	}
+
+	wantApps := []ApplicationReuse{
+		{Application: "config", SharedKeys: 3, Clusters: 2},
+		{Application: "app-a", SharedKeys: 1, Clusters: 1},
+		{Application: "app-b", SharedKeys: 1, Clusters: 1},
+	}
+	if !reflect.DeepEqual(report.Applications, wantApps) {
+		t.Errorf("applications = %+v, want %+v", report.Applications, wantApps)
+	}
+}
+
+func TestFindReusedValuesFilters(t *testing.T) {
+	srv := newReuseVault(t)
+
+	tests := []struct {
+		name string
+		opts ReuseOptions
+		want [][]string
+	}{
+		{
+			name: "key glob",
+			opts: ReuseOptions{KeyGlob: "*TOKEN*"},
+			want: [][]string{{"app-c/config#API_TOKEN", "app-d/config#API_TOKEN"}},
+		},
+		{
+			name: "min length",
+			opts: ReuseOptions{MinLength: 19},
+			want: [][]string{{"app-c/config#DB_PASSWORD", "general/dba/postgres/db01/app-a#POSTGRES_PASSWORD", "general/dba/postgres/db02/app-b#POSTGRES_PASSWORD"}},
+		},
+		{
+			name: "short values",
+			opts: ReuseOptions{MinLength: 4, KeyGlob: "*PORT"},
+			want: [][]string{{"app-d/config#PORT", "general/dba/postgres/db01/app-a#POSTGRES_PORT"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.BasePath = vaulttest.Mount
+			report, err := FindReusedValues(context.Background(), srv.Client(t), tt.opts, nil)
+			if err != nil {
+				t.Fatalf("FindReusedValues: %v", err)
+			}
+			if got := clusterOccurrences(report); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("clusters = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindReusedValuesRejectsNegativeAppSegment(t *testing.T) {
+	srv := newReuseVault(t)
+
+	if _, err := FindReusedValues(context.Background(), srv.Client(t), ReuseOptions{BasePath: vaulttest.Mount, AppSegment: -1}, nil); err == nil {
+		t.Errorf("FindReusedValues accepted app_segment -1")
+	}
+}
+
+func TestFindReusedValuesNeverReportsValues(t *testing.T) {
+	srv := newReuseVault(t)
+
+	previous := config.VaultAPIHMACKey
+	t.Cleanup(func() { config.VaultAPIHMACKey = previous })
+	config.VaultAPIHMACKey = "chave-do-servidor"
+
+	report, err := FindReusedValues(context.Background(), srv.Client(t), ReuseOptions{BasePath: vaulttest.Mount}, nil)
+	if err != nil {
+		t.Fatalf("FindReusedValues: %v", err)
+	}
+
+	body, err := json.Marshal(report)
+	if err != nil {
+		t.Fatalf("json.Marshal: %v", err)
+	}
+	for _, value := range []string{"senha-compartilhada", "token-repetido-123", "senha-exclusiva-e"} {
+		if strings.Contains(string(body), value) {
+			t.Errorf("report contains the value %q: %s", value, body)
+		}
+	}
+
+	// Com a chave configurada, a fingerprint é estável e reutilizável em old_password_hmac
+	if !report.StableFingerprints {
+		t.Errorf("stable_fingerprints = false with VAULT_API_HMAC_KEY set")
+	}
+	want := hex.EncodeToString(ValueHMAC([]byte("chave-do-servidor"), "senha-compartilhada"))
+	if len(report.Clusters) == 0 || report.Clusters[0].Fingerprint != want {
+		t.Errorf("first cluster fingerprint = %+v, want %s", report.Clusters, want)
+	}
+}
+
+func TestApplicationOf(t *testing.T) {
+	secret := KVPath{Mount: KVMount{Path: "secret", Version: 2}, Secret: "general/dba/postgres/db01/minha-app"}
+
+	tests := []struct {
+		segment int
+		want    string
+	}{
+		{0, "minha-app"},
+		{1, "general"},
+		{4, "db01"},
+		// Além do último segmento, vale o último
+		{9, "minha-app"},
+	}
+
+	for _, tt := range tests {
+		if got := applicationOf(secret, tt.segment); got != tt.want {
+			t.Errorf("applicationOf(segment %d) = %q, want %q", tt.segment, got, tt.want)
+		}
+	}
+}
+
+func TestSummarizeReuse(t *testing.T) {
+	groups := map[string][]ReuseOccurrence{
+		"unico": {{Path: "secret/data/a", Key: "K", Application: "a"}},
+		"par": {
+			{Path: "secret/data/b", Key: "K", Application: "b"},
+			{Path: "secret/data/a", Key: "K", Application: "a"},
+		},
+		"trio": {
+			{Path: "secret/data/a", Key: "Y", Application: "a"},
+			{Path: "secret/data/a", Key: "X", Application: "a"},
+			{Path: "secret/data/c", Key: "K", Application: "c"},
+		},
+	}
+
+	clusters, applications := summarizeReuse(groups)
+
+	// Maiores primeiro, ocorrências ordenadas por caminho e chave, grupos de um só descartados
+	wantClusters := []ReuseCluster{
+		{
+			Fingerprint:  "trio",
+			Count:        3,
+			Applications: []string{"a", "c"},
+			Occurrences: []ReuseOccurrence{
+				{Path: "secret/data/a", Key: "X", Application: "a"},
+				{Path: "secret/data/a", Key: "Y", Application: "a"},
+				{Path: "secret/data/c", Key: "K", Application: "c"},
+			},
+		},
+		{
+			Fingerprint:  "par",
+			Count:        2,
+			Applications: []string{"a", "b"},
+			Occurrences: []ReuseOccurrence{
+				{Path: "secret/data/a", Key: "K", Application: "a"},
+				{Path: "secret/data/b", Key: "K", Application: "b"},
+			},
+		},
+	}
+	if !reflect.DeepEqual(clusters, wantClusters) {
+		t.Errorf("clusters = %+v, want %+v", clusters, wantClusters)
+	}
+
+	// Uma aplicação conta cada grupo uma vez, mesmo com várias chaves nele
+	wantApps := []ApplicationReuse{
+		{Application: "a", SharedKeys: 3, Clusters: 2},
+		{Application: "b", SharedKeys: 1, Clusters: 1},
+		{Application: "c", SharedKeys: 1, Clusters: 1},
+	}
+	if !reflect.DeepEqual(applications, wantApps) {
+		t.Errorf("applications = %+v, want %+v", applications, wantApps)
+	}
+}
@@ -0,0 +1,159 @@
+package vault
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+	"strings"
+
+	"github.com/hashicorp/vault/api"
+)
+
+// Rewriter decide se um valor deve ser alterado e qual o novo valor. occurrences
+// é o número de trechos substituídos (0 indica que a chave não foi afetada).
+type Rewriter interface {
+	Rewrite(key, value string) (newValue string, occurrences int)
+}
+
+type RewriterFunc func(key, value string) (string, int)
+
+func (f RewriterFunc) Rewrite(key, value string) (string, int) {
+	return f(key, value)
+}
+
+// ReplaceMatching troca o valor inteiro por newValue sempre que matcher aceitar o par.
+func ReplaceMatching(matcher Matcher, newValue string) Rewriter {
+	return RewriterFunc(func(key, value string) (string, int) {
+		if !matcher.Match(key, value) {
+			return value, 0
+		}
+		return newValue, 1
+	})
+}
+
+func ReplaceSubstring(find, replace string) Rewriter {
+	return RewriterFunc(func(key, value string) (string, int) {
+		count := strings.Count(value, find)
+		if count == 0 {
+			return value, 0
+		}
+		return strings.ReplaceAll(value, find, replace), count
+	})
+}
+
+func ReplaceRegex(re *regexp.Regexp, replace string) Rewriter {
+	return RewriterFunc(func(key, value string) (string, int) {
+		count := len(re.FindAllStringIndex(value, -1))
+		if count == 0 {
+			return value, 0
+		}
+		return re.ReplaceAllString(value, replace), count
+	})
+}
+
+// OnlyKeys restringe rewriter às chaves aceitas por keys.
+func OnlyKeys(keys Matcher, rewriter Rewriter) Rewriter {
+	return RewriterFunc(func(key, value string) (string, int) {
+		if !keys.Match(key, value) {
+			return value, 0
+		}
+		return rewriter.Rewrite(key, value)
+	})
+}
+
+type MatchType string
+
+const (
+	MatchExact     MatchType = "exact"
+	MatchSubstring MatchType = "substring"
+	MatchRegex     MatchType = "regex"
+)
+
+type RewriteOptions struct {
+	BasePath   string
+	KeyGlob    string
+	MatchType  MatchType
+	Find       string
+	Replace    string
+	Mode       OperationMode
+	ShowValues bool
+
+	// AllowUnanchored aceita regex sem ^ ou $; sem ele, regex precisam ser ancoradas
+	AllowUnanchored bool
+
+	Filter PathFilter
+
+	// RunLog, se informado, registra as alterações do modo edit para rollback
+	RunLog *RunLog
+}
+
+func NewRewriter(opts RewriteOptions) (Rewriter, error) {
+	if opts.Find == "" {
+		return nil, fmt.Errorf("find is required")
+	}
+
+	var rewriter Rewriter
+	switch opts.MatchType {
+	case MatchExact:
+		rewriter = ReplaceMatching(ExactValue(opts.Find), opts.Replace)
+	case "", MatchSubstring:
+		rewriter = ReplaceSubstring(opts.Find, opts.Replace)
+	case MatchRegex:
+		re, err := regexp.Compile(opts.Find)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex: %v", err)
+		}
+		// Uma regex que aceita "" casaria com todo valor (inclusive entre cada caractere)
+		if re.MatchString("") {
+			return nil, fmt.Errorf("invalid regex: '%s' matches the empty string", opts.Find)
+		}
+		if !opts.AllowUnanchored && !isAnchored(opts.Find) {
+			return nil, fmt.Errorf("regex '%s' is not anchored; use ^ or $ or set allow_unanchored", opts.Find)
+		}
+		rewriter = ReplaceRegex(re, opts.Replace)
+	default:
+		return nil, fmt.Errorf("invalid match type '%s' (use exact, substring or regex)", opts.MatchType)
+	}
+
+	if opts.KeyGlob != "" {
+		matcher, err := NewMatcher(SearchCriteria{KeyGlob: opts.KeyGlob})
+		if err != nil {
+			return nil, err
+		}
+		rewriter = OnlyKeys(matcher, rewriter)
+	}
+
+	return rewriter, nil
+}
+
+// isAnchored indica se pattern começa com ^ (ou \A) ou termina com $ (ou \z).
+func isAnchored(pattern string) bool {
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return false
+	}
+
+	first, last := re, re
+	if re.Op == syntax.OpConcat && len(re.Sub) > 0 {
+		first, last = re.Sub[0], re.Sub[len(re.Sub)-1]
+	}
+
+	return first.Op == syntax.OpBeginText || first.Op == syntax.OpBeginLine ||
+		last.Op == syntax.OpEndText || last.Op == syntax.OpEndLine
+}
+
+// RewriteValues generaliza SearchAndReplacePasswordDirect: em vez de trocar uma senha
+// exata, reescreve valores (ex: hostnames) por substring ou regex nas chaves escolhidas.
+func RewriteValues(ctx context.Context, client *api.Client, opts RewriteOptions, observer Observer) ([]PasswordUpdateResult, error) {
+	if opts.Mode != ListMode && opts.Mode != EditMode {
+		return nil, fmt.Errorf("modo de operação inválido: %s (use 'list' ou 'edit')", opts.Mode)
+	}
+
+	rewriter, err := NewRewriter(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return rewriteTree(ctx, client, opts.BasePath, rewriter, opts.Mode, opts.ShowValues, observer, TreeOptions{Filter: opts.Filter, RunLog: opts.RunLog})
+}
@@ -0,0 +1,290 @@
+package vault
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+)
+
+const runRetention = 24 * time.Hour
+
+// PathChange registra o estado de um caminho antes e depois de uma execução em modo
+// edit. Em KV v1 não há versões, então os próprios dados são guardados para o rollback.
+type PathChange struct {
+	Path            string `json:"path"`
+	KVVersion       int    `json:"kv_version"`
+	PreviousVersion int    `json:"previous_version,omitempty"`
+	Version         int    `json:"version,omitempty"`
+
+	kvPath       KVPath
+	previousData map[string]interface{}
+	writtenData  map[string]interface{}
+	restored     bool
+}
+
+type RollbackState string
+
+const (
+	RollbackInProgress RollbackState = "in_progress"
+	RollbackPartial    RollbackState = "partial"
+	RollbackComplete   RollbackState = "complete"
+)
+
+var (
+	ErrRollbackInProgress = errors.New("a rollback of this run is already in progress")
+	ErrAlreadyRolledBack  = errors.New("this run was already rolled back")
+)
+
+// RunLog acumula as alterações feitas por uma execução em modo edit. RolledBack só é
+// preenchido quando todos os caminhos foram restaurados.
+type RunLog struct {
+	ID            string        `json:"id"`
+	Operation     string        `json:"operation"`
+	BasePath      string        `json:"base_path"`
+	CreatedAt     time.Time     `json:"created_at"`
+	RollbackState RollbackState `json:"rollback_state,omitempty"`
+	RolledBack    *time.Time    `json:"rolled_back_at,omitempty"`
+	Changes       []PathChange  `json:"changes"`
+
+	owner string
+	mu    sync.Mutex
+}
+
+var (
+	runsMu sync.Mutex
+	runs   = make(map[string]*RunLog)
+)
+
+// NewRunLog cria e registra o log de uma execução; fica disponível por 24 horas, só
+// para quem tiver o mesmo owner (o mesmo dono dos jobs).
+func NewRunLog(operation, basePath, owner string) (*RunLog, error) {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, fmt.Errorf("failed to generate run id: %w", err)
+	}
+
+	run := &RunLog{
+		ID:        hex.EncodeToString(buf),
+		Operation: operation,
+		BasePath:  basePath,
+		CreatedAt: time.Now(),
+		Changes:   []PathChange{},
+		owner:     owner,
+	}
+
+	runsMu.Lock()
+	defer runsMu.Unlock()
+
+	for id, existing := range runs {
+		if time.Since(existing.CreatedAt) > runRetention {
+			delete(runs, id)
+		}
+	}
+	runs[run.ID] = run
+
+	return run, nil
+}
+
+// GetRunLog trata execuções de outro owner como inexistentes.
+func GetRunLog(id, owner string) (*RunLog, bool) {
+	runsMu.Lock()
+	defer runsMu.Unlock()
+
+	run, ok := runs[id]
+	if !ok || run.owner != owner {
+		return nil, false
+	}
+	return run, true
+}
+
+// record é seguro para chamadas concorrentes; run nil não registra nada.
+func (run *RunLog) record(kvPath KVPath, previousVersion int, previousData map[string]interface{}, version int, writtenData map[string]interface{}) {
+	if run == nil {
+		return
+	}
+
+	change := PathChange{
+		Path:      kvPath.DataPath(),
+		KVVersion: kvPath.Mount.Version,
+		kvPath:    kvPath,
+	}
+	if kvPath.Mount.Version == 2 {
+		change.PreviousVersion = previousVersion
+		change.Version = version
+	} else {
+		change.previousData = previousData
+		change.writtenData = writtenData
+	}
+
+	run.mu.Lock()
+	defer run.mu.Unlock()
+	run.Changes = append(run.Changes, change)
+}
+
+// Snapshot devolve uma cópia do log com as alterações ordenadas por caminho.
+func (run *RunLog) Snapshot() RunLog {
+	run.mu.Lock()
+	defer run.mu.Unlock()
+
+	changes := append([]PathChange(nil), run.Changes...)
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Path < changes[j].Path
+	})
+
+	return RunLog{
+		ID:            run.ID,
+		Operation:     run.Operation,
+		BasePath:      run.BasePath,
+		CreatedAt:     run.CreatedAt,
+		RollbackState: run.RollbackState,
+		RolledBack:    run.RolledBack,
+		Changes:       changes,
+	}
+}
+
+type RollbackStatus string
+
+const (
+	RollbackRestored      RollbackStatus = "restored"
+	RollbackModifiedSince RollbackStatus = "modified_since"
+	RollbackFailed        RollbackStatus = "failed"
+)
+
+type RollbackResult struct {
+	Path            string         `json:"path"`
+	Status          RollbackStatus `json:"status"`
+	PreviousVersion int            `json:"previous_version,omitempty"`
+	Version         int            `json:"version,omitempty"`
+	CurrentVersion  int            `json:"current_version,omitempty"`
+	Error           string         `json:"error,omitempty"`
+}
+
+// Rollback devolve cada caminho alterado pela execução ao estado anterior. Caminhos
+// alterados de novo depois da execução não são tocados e voltam como modified_since.
+// Só um rollback roda por vez; depois de um rollback parcial, um novo rollback tenta
+// apenas os caminhos que ainda não foram restaurados.
+func (run *RunLog) Rollback(ctx context.Context, client *api.Client) ([]RollbackResult, error) {
+	run.mu.Lock()
+	switch run.RollbackState {
+	case RollbackInProgress:
+		run.mu.Unlock()
+		return nil, ErrRollbackInProgress
+	case RollbackComplete:
+		run.mu.Unlock()
+		return nil, ErrAlreadyRolledBack
+	}
+	run.RollbackState = RollbackInProgress
+
+	var pending []int
+	for i, change := range run.Changes {
+		if !change.restored {
+			pending = append(pending, i)
+		}
+	}
+	sort.Slice(pending, func(a, b int) bool {
+		return run.Changes[pending[a]].Path < run.Changes[pending[b]].Path
+	})
+	changes := make([]PathChange, len(pending))
+	for i, index := range pending {
+		changes[i] = run.Changes[index]
+	}
+	run.mu.Unlock()
+
+	results := make([]RollbackResult, 0, len(changes))
+	for _, change := range changes {
+		if ctx.Err() != nil {
+			break
+		}
+		results = append(results, rollbackChange(ctx, client, change))
+	}
+
+	run.mu.Lock()
+	defer run.mu.Unlock()
+
+	complete := len(results) == len(changes)
+	for i, result := range results {
+		if result.Status == RollbackRestored {
+			run.Changes[pending[i]].restored = true
+		} else {
+			complete = false
+		}
+	}
+
+	if complete {
+		now := time.Now()
+		run.RollbackState = RollbackComplete
+		run.RolledBack = &now
+	} else {
+		run.RollbackState = RollbackPartial
+	}
+
+	return results, nil
+}
+
+func rollbackChange(ctx context.Context, client *api.Client, change PathChange) RollbackResult {
+	result := RollbackResult{
+		Path:            change.Path,
+		PreviousVersion: change.PreviousVersion,
+		Version:         change.Version,
+	}
+
+	fail := func(err error) RollbackResult {
+		result.Status = RollbackFailed
+		result.Error = err.Error()
+		return result
+	}
+
+	// O caminho pode ter sido protegido depois da execução
+	if err := checkProtected(change.kvPath); err != nil {
+		return fail(err)
+	}
+
+	if change.KVVersion != 2 {
+		current, _, err := readSecretDataWithContext(ctx, client, change.kvPath)
+		if err != nil {
+			return fail(err)
+		}
+		if !reflect.DeepEqual(current, change.writtenData) {
+			result.Status = RollbackModifiedSince
+			return result
+		}
+		if _, err := writeSecretDataWithContext(ctx, client, change.kvPath, change.previousData, StoreOptions{Mode: ReplaceWrite}); err != nil {
+			return fail(err)
+		}
+		result.Status = RollbackRestored
+		return result
+	}
+
+	previous, err := client.KVv2(change.kvPath.Mount.Path).GetVersion(ctx, change.kvPath.Secret, change.PreviousVersion)
+	if err != nil {
+		return fail(fmt.Errorf("failed to read version %d: %w", change.PreviousVersion, err))
+	}
+	if previous == nil || previous.Data == nil {
+		return fail(fmt.Errorf("version %d is deleted or destroyed", change.PreviousVersion))
+	}
+
+	// CAS na versão gravada pela execução: se alguém alterou o segredo depois, nada é sobrescrito
+	version, err := writeSecretDataWithContext(ctx, client, change.kvPath, previous.Data, StoreOptions{Mode: ReplaceWrite, CAS: &change.Version})
+	if errors.Is(err, ErrCASMismatch) {
+		result.Status = RollbackModifiedSince
+		if _, current, readErr := readSecretDataWithContext(ctx, client, change.kvPath); readErr == nil {
+			result.CurrentVersion = current
+		}
+		return result
+	}
+	if err != nil {
+		return fail(err)
+	}
+
+	result.Status = RollbackRestored
+	result.CurrentVersion = version
+	return result
+}
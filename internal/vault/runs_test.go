@@ -0,0 +1,193 @@
+package vault
+
+import (
+	"context"
+	"devops-go-vault-api/internal/vault/vaulttest"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/vault/api"
+)
+
+// editRun troca senha-antiga por senha-nova sob basePath registrando as alterações num RunLog.
+func editRun(t *testing.T, client *api.Client, basePath string) *RunLog {
+	t.Helper()
+
+	run, err := NewRunLog("updatePassword", basePath, "owner")
+	if err != nil {
+		t.Fatalf("NewRunLog: %v", err)
+	}
+
+	updates, err := SearchAndReplaceMatching(context.Background(), client, basePath, ExactValue("senha-antiga"), "senha-nova", EditMode, nil, TreeOptions{RunLog: run})
+	if err != nil {
+		t.Fatalf("SearchAndReplaceMatching: %v", err)
+	}
+	for _, update := range updates {
+		if update.Error != "" {
+			t.Fatalf("update of '%s' failed: %s", update.Path, update.Error)
+		}
+	}
+	return run
+}
+
+func rollbackStatuses(results []RollbackResult) map[string]RollbackStatus {
+	statuses := make(map[string]RollbackStatus)
+	for _, result := range results {
+		statuses[result.Path] = result.Status
+	}
+	return statuses
+}
+
+func TestGetRunLogIsScopedToOwner(t *testing.T) {
+	run, err := NewRunLog("updatePassword", vaulttest.Mount, "owner-a")
+	if err != nil {
+		t.Fatalf("NewRunLog: %v", err)
+	}
+
+	if _, ok := GetRunLog(run.ID, "owner-b"); ok {
+		t.Errorf("run of owner-a visible to owner-b")
+	}
+	if got, ok := GetRunLog(run.ID, "owner-a"); !ok || got != run {
+		t.Errorf("GetRunLog(owner-a) = %v, %v, want the run", got, ok)
+	}
+}
+
+func TestRollbackRestoresPreviousVersions(t *testing.T) {
+	srv := vaulttest.NewServer(t)
+	srv.Put("app/a", map[string]interface{}{"DB_PASSWORD": "senha-antiga"})
+	srv.Put("app/b", map[string]interface{}{"DB_PASSWORD": "senha-antiga", "USER": "app"})
+	client := srv.Client(t)
+
+	run := editRun(t, client, vaulttest.Mount+"/app")
+
+	results, err := run.Rollback(context.Background(), client)
+	if err != nil {
+		t.Fatalf("Rollback: %v", err)
+	}
+
+	want := map[string]RollbackStatus{
+		vaulttest.Mount + "/data/app/a": RollbackRestored,
+		vaulttest.Mount + "/data/app/b": RollbackRestored,
+	}
+	if got := rollbackStatuses(results); !reflect.DeepEqual(got, want) {
+		t.Errorf("statuses = %v, want %v", got, want)
+	}
+	// A versão anterior é regravada como uma nova versão
+	if data := srv.Data("app/b"); data["DB_PASSWORD"] != "senha-antiga" || data["USER"] != "app" || srv.Version("app/b") != 3 {
+		t.Errorf("app/b = %v (v%d), want the original data as v3", data, srv.Version("app/b"))
+	}
+
+	snapshot := run.Snapshot()
+	if snapshot.RollbackState != RollbackComplete || snapshot.RolledBack == nil {
+		t.Errorf("state = %q, rolled back at %v, want complete", snapshot.RollbackState, snapshot.RolledBack)
+	}
+
+	if _, err := run.Rollback(context.Background(), client); !errors.Is(err, ErrAlreadyRolledBack) {
+		t.Errorf("second Rollback error = %v, want ErrAlreadyRolledBack", err)
+	}
+}
+
+func TestRollbackSkipsPathsModifiedSince(t *testing.T) {
+	srv := vaulttest.NewServer(t)
+	srv.Put("app/config", map[string]interface{}{"DB_PASSWORD": "senha-antiga"})
+	client := srv.Client(t)
+
+	run := editRun(t, client, vaulttest.Mount+"/app")
+	srv.Put("app/config", map[string]interface{}{"DB_PASSWORD": "senha-manual"})
+
+	results, err := run.Rollback(context.Background(), client)
+	if err != nil {
+		t.Fatalf("Rollback: %v", err)
+	}
+
+	if len(results) != 1 || results[0].Status != RollbackModifiedSince || results[0].CurrentVersion != 3 {
+		t.Fatalf("results = %+v, want modified_since at current version 3", results)
+	}
+	if data := srv.Data("app/config"); data["DB_PASSWORD"] != "senha-manual" {
+		t.Errorf("data = %v, want the later write kept", data)
+	}
+	if state := run.Snapshot().RollbackState; state != RollbackPartial {
+		t.Errorf("state = %q, want partial", state)
+	}
+}
+
+func TestRollbackRestoresKVv1Data(t *testing.T) {
+	srv := vaulttest.NewServer(t)
+	srv.PutV1("app/restored", map[string]interface{}{"DB_PASSWORD": "senha-antiga", "USER": "app"})
+	srv.PutV1("app/changed", map[string]interface{}{"DB_PASSWORD": "senha-antiga"})
+	client := srv.Client(t)
+
+	run := editRun(t, client, vaulttest.MountV1+"/app")
+	if data := srv.DataV1("app/restored"); data["DB_PASSWORD"] != "senha-nova" {
+		t.Fatalf("data after the run = %v, want the new password", data)
+	}
+	// Sem versões, a alteração posterior só é detectada comparando os dados gravados
+	srv.PutV1("app/changed", map[string]interface{}{"DB_PASSWORD": "senha-manual"})
+
+	results, err := run.Rollback(context.Background(), client)
+	if err != nil {
+		t.Fatalf("Rollback: %v", err)
+	}
+
+	want := map[string]RollbackStatus{
+		vaulttest.MountV1 + "/app/restored": RollbackRestored,
+		vaulttest.MountV1 + "/app/changed":  RollbackModifiedSince,
+	}
+	if got := rollbackStatuses(results); !reflect.DeepEqual(got, want) {
+		t.Errorf("statuses = %v, want %v", got, want)
+	}
+	if data := srv.DataV1("app/restored"); data["DB_PASSWORD"] != "senha-antiga" || data["USER"] != "app" {
+		t.Errorf("app/restored = %v, want the original data", data)
+	}
+	if data := srv.DataV1("app/changed"); data["DB_PASSWORD"] != "senha-manual" {
+		t.Errorf("app/changed = %v, want the later write kept", data)
+	}
+}
+
+func TestRollbackRetriesOnlyPendingPaths(t *testing.T) {
+	srv := vaulttest.NewServer(t)
+	srv.Put("app/a", map[string]interface{}{"DB_PASSWORD": "senha-antiga"})
+	srv.Put("app/b", map[string]interface{}{"DB_PASSWORD": "senha-antiga"})
+	client := srv.Client(t)
+
+	run := editRun(t, client, vaulttest.Mount+"/app")
+	srv.FailWrites("app/a")
+
+	results, err := run.Rollback(context.Background(), client)
+	if err != nil {
+		t.Fatalf("Rollback: %v", err)
+	}
+
+	want := map[string]RollbackStatus{
+		vaulttest.Mount + "/data/app/a": RollbackFailed,
+		vaulttest.Mount + "/data/app/b": RollbackRestored,
+	}
+	if got := rollbackStatuses(results); !reflect.DeepEqual(got, want) {
+		t.Errorf("statuses = %v, want %v", got, want)
+	}
+	if state := run.Snapshot().RollbackState; state != RollbackPartial {
+		t.Errorf("state = %q, want partial", state)
+	}
+
+	srv.AllowWrites("app/a")
+	versionB := srv.Version("app/b")
+
+	results, err = run.Rollback(context.Background(), client)
+	if err != nil {
+		t.Fatalf("second Rollback: %v", err)
+	}
+
+	if len(results) != 1 || results[0].Path != vaulttest.Mount+"/data/app/a" || results[0].Status != RollbackRestored {
+		t.Fatalf("results = %+v, want only app/a restored", results)
+	}
+	if srv.Version("app/b") != versionB {
+		t.Errorf("app/b was written again by the retry")
+	}
+	if data := srv.Data("app/a"); data["DB_PASSWORD"] != "senha-antiga" {
+		t.Errorf("app/a = %v, want the original data", data)
+	}
+	if state := run.Snapshot().RollbackState; state != RollbackComplete {
+		t.Errorf("state = %q, want complete", state)
+	}
+}
@@ -0,0 +1,86 @@
+package vault
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/hashicorp/vault/api"
+)
+
+// ScanFailure é um diretório que não pôde ser listado ou um segredo que não pôde ser
+// lido; o que estiver abaixo dele fica fora do resultado da varredura.
+type ScanFailure struct {
+	Path  string `json:"path"`
+	Error string `json:"error"`
+
+	secret KVPath
+	isDir  bool
+}
+
+// ScanFunc recebe os dados de cada segredo lido; pode ser chamada em paralelo. Chamadas
+// ao Vault feitas em ScanFunc devem usar ctx, que carrega o rate limit da varredura.
+type ScanFunc func(ctx context.Context, secret KVPath, data map[string]interface{})
+
+// scanTree lê todos os segredos abaixo de basePath (incluindo o próprio basePath, se for
+// um segredo) sem alterar nada. Diretórios que não puderem ser listados e segredos que
+// não puderem ser lidos são devolvidos em failures, ordenados por caminho.
+func scanTree(ctx context.Context, client *api.Client, basePath string, filter PathFilter, observer Observer, visit ScanFunc) ([]ScanFailure, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+
+	emit(observer, Event{Type: EventRunStarted, Path: basePath, Message: string(ListMode)})
+
+	root, err := ResolvePath(client, basePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var mu sync.Mutex
+	failures := []ScanFailure{}
+	addFailure := func(failure ScanFailure) {
+		mu.Lock()
+		defer mu.Unlock()
+		failures = append(failures, failure)
+	}
+
+	read := func(ctx context.Context, secret KVPath) {
+		data, _, err := readSecretDataWithContext(ctx, client, secret)
+		if err != nil {
+			if ctx.Err() == nil {
+				emit(observer, Event{Type: EventReadFailed, Path: secret.DataPath(), Error: err.Error()})
+				addFailure(ScanFailure{Path: secret.DataPath(), Error: err.Error(), secret: secret})
+			}
+			return
+		}
+		if data != nil {
+			visit(ctx, secret, data)
+		}
+	}
+
+	walker := NewWalker(client)
+	walker.Filter = filter
+	walker.Observer = observer
+	walker.OnListError = func(dir KVPath, err error) {
+		addFailure(ScanFailure{Path: dir.ListPath(), Error: err.Error(), secret: dir, isDir: true})
+	}
+
+	err = walker.Walk(ctx, root, func(ctx context.Context, secret KVPath) error {
+		read(ctx, secret)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if root.Secret != "" && !filter.excludes(root) && filter.includes(root) {
+		emit(observer, Event{Type: EventCheckSecret, Path: root.DataPath()})
+		read(ctx, root)
+	}
+
+	sort.Slice(failures, func(i, j int) bool {
+		return failures[i].Path < failures[j].Path
+	})
+	return failures, nil
+}
@@ -0,0 +1,25 @@
+package vault
+
+import (
+	"context"
+
+	"github.com/hashicorp/vault/api"
+)
+
+type SearchMatch struct {
+	Path string `json:"path"`
+	Key  string `json:"key"`
+}
+
+// SearchSecrets lista os caminhos/chaves abaixo de basePath aceitos por matcher,
+// sem alterar nada e sem expor os valores.
+func SearchSecrets(ctx context.Context, client *api.Client, basePath string, matcher Matcher, observer Observer) ([]SearchMatch, error) {
+	results, err := rewriteTree(ctx, client, basePath, ReplaceMatching(matcher, ""), ListMode, false, observer, TreeOptions{})
+
+	matches := make([]SearchMatch, 0, len(results))
+	for _, result := range results {
+		matches = append(matches, SearchMatch{Path: result.Path, Key: result.Key})
+	}
+
+	return matches, err
+}
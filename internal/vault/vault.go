@@ -1,54 +1,215 @@
 package vault
 
 import (
-	"devops-go-vault-api/config"
+	"context"
+	"errors"
 	"fmt"
+	"strings"
+
 	"github.com/hashicorp/vault/api"
 )
 
-func StoreInVault(path string, data map[string]string) error {
+type WriteMode string
+
+const (
+	ReplaceWrite    WriteMode = "replace"
+	MergeWrite      WriteMode = "merge"
+	CreateOnlyWrite WriteMode = "create-only"
+)
+
+var ErrSecretExists = errors.New("secret already exists")
+var ErrCASMismatch = errors.New("check-and-set version mismatch")
 
-	conf := api.DefaultConfig()
-	conf.Address = config.VaultAddress
+// CAS é a versão esperada do segredo (apenas KV v2); nil grava sem verificação.
+type StoreOptions struct {
+	Mode WriteMode
+	CAS  *int
+}
 
-	client, err := api.NewClient(conf)
+func ParseWriteMode(mode string) (WriteMode, error) {
+	switch WriteMode(strings.ToLower(mode)) {
+	case "", ReplaceWrite:
+		return ReplaceWrite, nil
+	case MergeWrite:
+		return MergeWrite, nil
+	case CreateOnlyWrite:
+		return CreateOnlyWrite, nil
+	default:
+		return "", fmt.Errorf("invalid write mode '%s' (use replace, merge or create-only)", mode)
+	}
+}
+
+func StoreInVault(client *api.Client, path string, data map[string]string, opts StoreOptions) error {
+	return StoreInVaultWithContext(context.Background(), client, path, data, opts)
+}
+
+// StoreInVaultWithContext grava respeitando o cancelamento e o rate limit de ctx.
+func StoreInVaultWithContext(ctx context.Context, client *api.Client, path string, data map[string]string, opts StoreOptions) error {
+	kvPath, err := ResolvePath(client, path)
 	if err != nil {
 		return err
 	}
 
-	client.SetToken(config.VaultToken)
+	if kvPath.Secret == "" {
+		return fmt.Errorf("path '%s' points to a mount, not a secret", path)
+	}
+
+	if err := checkProtected(kvPath); err != nil {
+		return err
+	}
 
-	secretData := map[string]interface{}{
-		"data": data,
+	secretData := make(map[string]interface{})
+	for key, value := range data {
+		secretData[key] = value
 	}
 
-	_, err = client.Logical().Write(path, secretData)
+	_, err = writeSecretDataWithContext(ctx, client, kvPath, secretData, opts)
 	return err
 }
 
-func getClient() (*api.Client, error) {
-	conf := api.DefaultConfig()
-	conf.Address = config.VaultAddress // Usa o endereço do Vault do .env
+// writeSecretData grava data em kvPath e devolve a nova versão (0 em KV v1).
+func writeSecretData(client *api.Client, kvPath KVPath, data map[string]interface{}, opts StoreOptions) (int, error) {
+	return writeSecretDataWithContext(context.Background(), client, kvPath, data, opts)
+}
+
+func writeSecretDataWithContext(ctx context.Context, client *api.Client, kvPath KVPath, data map[string]interface{}, opts StoreOptions) (int, error) {
+	if err := waitLimit(ctx); err != nil {
+		return 0, err
+	}
+
+	if opts.Mode == "" {
+		opts.Mode = ReplaceWrite
+	}
+
+	if kvPath.Mount.Version == 2 {
+		return storeKVv2(ctx, client, kvPath, data, opts)
+	}
+
+	if opts.CAS != nil {
+		return 0, fmt.Errorf("mount '%s' is KV v1 and does not support check-and-set", kvPath.Mount.Path)
+	}
+	return 0, storeKVv1(ctx, client, kvPath, data, opts)
+}
+
+func storeKVv2(ctx context.Context, client *api.Client, kvPath KVPath, data map[string]interface{}, opts StoreOptions) (int, error) {
+	kv := client.KVv2(kvPath.Mount.Path)
 
-	client, err := api.NewClient(conf)
+	var kvOpts []api.KVOption
+	if opts.CAS != nil {
+		kvOpts = append(kvOpts, api.WithCheckAndSet(*opts.CAS))
+	}
+
+	var written *api.KVSecret
+	var err error
+	switch opts.Mode {
+	case ReplaceWrite:
+		written, err = kv.Put(ctx, kvPath.Secret, data, kvOpts...)
+	case MergeWrite:
+		written, err = kv.Patch(ctx, kvPath.Secret, data, kvOpts...)
+		if errors.Is(err, api.ErrSecretNotFound) {
+			written, err = kv.Put(ctx, kvPath.Secret, data, kvOpts...)
+		}
+	case CreateOnlyWrite:
+		if opts.CAS != nil && *opts.CAS != 0 {
+			return 0, fmt.Errorf("create-only writes always use cas=0")
+		}
+		written, err = kv.Put(ctx, kvPath.Secret, data, api.WithCheckAndSet(0))
+		if isCASMismatch(err) {
+			return 0, fmt.Errorf("%w: at path '%s'", ErrSecretExists, kvPath)
+		}
+	default:
+		return 0, fmt.Errorf("invalid write mode '%s'", opts.Mode)
+	}
+
+	if isCASMismatch(err) && opts.CAS != nil {
+		return 0, fmt.Errorf("%w: at path '%s' (expected version %d)", ErrCASMismatch, kvPath, *opts.CAS)
+	}
 	if err != nil {
-		return nil, err
+		return 0, fmt.Errorf("failed to write secret at path '%s': %w", kvPath, err)
+	}
+
+	if written != nil && written.VersionMetadata != nil {
+		return written.VersionMetadata.Version, nil
 	}
+	return 0, nil
+}
+
+// KV v1 não tem PATCH nem CAS, então merge e create-only são feitos com leitura
+// seguida de escrita.
+func storeKVv1(ctx context.Context, client *api.Client, kvPath KVPath, data map[string]interface{}, opts StoreOptions) error {
+	kv := client.KVv1(kvPath.Mount.Path)
 
-	client.SetToken(config.VaultToken)
+	if opts.Mode != ReplaceWrite {
+		current, err := kv.Get(ctx, kvPath.Secret)
+		if err != nil && !errors.Is(err, api.ErrSecretNotFound) {
+			return fmt.Errorf("failed to read secret at path '%s': %w", kvPath, err)
+		}
 
-	return client, nil
+		exists := err == nil && current != nil
+		switch opts.Mode {
+		case CreateOnlyWrite:
+			if exists {
+				return fmt.Errorf("%w: at path '%s'", ErrSecretExists, kvPath)
+			}
+		case MergeWrite:
+			if exists {
+				merged := make(map[string]interface{})
+				for key, value := range current.Data {
+					merged[key] = value
+				}
+				for key, value := range data {
+					merged[key] = value
+				}
+				data = merged
+			}
+		default:
+			return fmt.Errorf("invalid write mode '%s'", opts.Mode)
+		}
+	}
+
+	// No KV v1 o merge faz uma leitura antes da escrita
+	if opts.Mode != ReplaceWrite {
+		if err := waitLimit(ctx); err != nil {
+			return err
+		}
+	}
+	if err := kv.Put(ctx, kvPath.Secret, data); err != nil {
+		return fmt.Errorf("failed to write secret at path '%s': %w", kvPath, err)
+	}
+	return nil
 }
 
-func ListSecrets(path string) ([]string, error) {
-	client, err := getClient()
+func isCASMismatch(err error) bool {
+	var respErr *api.ResponseError
+	if !errors.As(err, &respErr) {
+		return false
+	}
+
+	for _, msg := range respErr.Errors {
+		if strings.Contains(msg, "check-and-set") {
+			return true
+		}
+	}
+	return false
+}
+
+func ListSecrets(client *api.Client, path string) ([]string, error) {
+	kvPath, err := ResolvePath(client, path)
 	if err != nil {
 		return nil, err
 	}
 
-	secret, err := client.Logical().List(path)
+	return listKeys(client, kvPath)
+}
+
+func listKeys(client *api.Client, kvPath KVPath) ([]string, error) {
+	return listKeysWithContext(context.Background(), client, kvPath)
+}
+
+func listKeysWithContext(ctx context.Context, client *api.Client, kvPath KVPath) ([]string, error) {
+	secret, err := client.Logical().ListWithContext(ctx, kvPath.ListPath())
 	if err != nil {
-		return nil, fmt.Errorf("failed to list secrets at path '%s': %v", path, err)
+		return nil, fmt.Errorf("failed to list secrets at path '%s': %v", kvPath, err)
 	}
 
 	if secret == nil || secret.Data == nil {
@@ -66,18 +227,111 @@ func ListSecrets(path string) ([]string, error) {
 	return []string{}, nil
 }
 
-func DeleteSecret(path string) error {
-	client, err := getClient()
+type DeleteMode string
+
+const (
+	SoftDelete     DeleteMode = "soft"
+	UndeleteMode   DeleteMode = "undelete"
+	DestroyDelete  DeleteMode = "destroy"
+	MetadataDelete DeleteMode = "metadata"
+)
+
+// ErrKVv1DeleteMode indica um modo de deleção que só existe no KV v2.
+var ErrKVv1DeleteMode = errors.New("KV v1 only supports the permanent 'metadata' delete mode")
+
+// Versions vazio significa a versão atual em soft e undelete; destroy exige versões explícitas.
+type DeleteOptions struct {
+	Mode     DeleteMode
+	Versions []int
+}
+
+func ParseDeleteMode(mode string) (DeleteMode, error) {
+	switch DeleteMode(strings.ToLower(mode)) {
+	case "", SoftDelete:
+		return SoftDelete, nil
+	case UndeleteMode:
+		return UndeleteMode, nil
+	case DestroyDelete:
+		return DestroyDelete, nil
+	case MetadataDelete:
+		return MetadataDelete, nil
+	default:
+		return "", fmt.Errorf("invalid delete mode '%s' (use soft, undelete, destroy or metadata)", mode)
+	}
+}
+
+func DeleteSecret(client *api.Client, path string, opts DeleteOptions) error {
+	return deleteSecret(context.Background(), client, path, opts)
+}
+
+func deleteSecret(ctx context.Context, client *api.Client, path string, opts DeleteOptions) error {
+	kvPath, err := ResolvePath(client, path)
 	if err != nil {
 		return err
 	}
 
-	metadataPath := fmt.Sprintf("secret/metadata/%s", path)
+	if kvPath.Secret == "" {
+		return fmt.Errorf("path '%s' points to a mount, not a secret", path)
+	}
+
+	if err := checkProtected(kvPath); err != nil {
+		return err
+	}
+
+	if opts.Mode == "" {
+		opts.Mode = SoftDelete
+	}
+
+	if kvPath.Mount.Version != 2 && opts.Mode != MetadataDelete {
+		return fmt.Errorf("%w: mount '%s'", ErrKVv1DeleteMode, kvPath.Mount.Path)
+	}
+
+	if err := waitLimit(ctx); err != nil {
+		return err
+	}
+
+	if kvPath.Mount.Version != 2 {
+		err = client.KVv1(kvPath.Mount.Path).Delete(ctx, kvPath.Secret)
+	} else {
+		err = deleteKVv2(ctx, client.KVv2(kvPath.Mount.Path), kvPath.Secret, opts)
+	}
 
-	_, err = client.Logical().Delete(metadataPath)
 	if err != nil {
-		return fmt.Errorf("failed to delete secret at path '%s': %v", path, err)
+		return fmt.Errorf("failed to %s secret at path '%s': %w", opts.Mode, path, err)
 	}
 
 	return nil
 }
+
+func deleteKVv2(ctx context.Context, kv *api.KVv2, secretPath string, opts DeleteOptions) error {
+	switch opts.Mode {
+	case SoftDelete:
+		if len(opts.Versions) == 0 {
+			return kv.Delete(ctx, secretPath)
+		}
+		return kv.DeleteVersions(ctx, secretPath, opts.Versions)
+	case UndeleteMode:
+		versions := opts.Versions
+		if len(versions) == 0 {
+			metadata, err := kv.GetMetadata(ctx, secretPath)
+			if err != nil {
+				return err
+			}
+			versions = []int{metadata.CurrentVersion}
+
+			if err := waitLimit(ctx); err != nil {
+				return err
+			}
+		}
+		return kv.Undelete(ctx, secretPath, versions)
+	case DestroyDelete:
+		if len(opts.Versions) == 0 {
+			return fmt.Errorf("destroy requires the list of versions")
+		}
+		return kv.Destroy(ctx, secretPath, opts.Versions)
+	case MetadataDelete:
+		return kv.DeleteMetadata(ctx, secretPath)
+	default:
+		return fmt.Errorf("invalid delete mode '%s'", opts.Mode)
+	}
+}
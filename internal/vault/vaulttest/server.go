@@ -0,0 +1,392 @@
+// Package vaulttest emula, em memória, o subconjunto da API do Vault usado pelo serviço
+// (um mount KV v2 e um KV v1), para testes sem um Vault real.
+package vaulttest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+)
+
+// Mount é o mount KV v2 emulado pelo Server; MountV1 é o mount KV v1.
+const (
+	Mount   = "secret"
+	MountV1 = "legacy"
+)
+
+type version struct {
+	data    map[string]interface{}
+	deleted bool
+}
+
+type Server struct {
+	*httptest.Server
+
+	mu          sync.Mutex
+	secrets     map[string][]version
+	secretsV1   map[string]map[string]interface{}
+	failWrites  map[string]bool
+	interleaved map[string]interleavedWrite
+}
+
+type interleavedWrite struct {
+	data  map[string]interface{}
+	times int
+}
+
+// NewServer sobe o servidor e o encerra ao final do teste.
+func NewServer(t testing.TB) *Server {
+	s := &Server{
+		secrets:     make(map[string][]version),
+		secretsV1:   make(map[string]map[string]interface{}),
+		failWrites:  make(map[string]bool),
+		interleaved: make(map[string]interleavedWrite),
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// Client devolve um client sem novas tentativas, para que falhas simuladas sejam imediatas.
+func (s *Server) Client(t testing.TB) *api.Client {
+	client, err := api.NewClient(&api.Config{Address: s.URL})
+	if err != nil {
+		t.Fatalf("failed to create vault client: %v", err)
+	}
+	client.SetToken("test-token")
+	client.SetMaxRetries(0)
+	return client
+}
+
+// Put grava uma nova versão de path (caminho lógico, sem o mount).
+func (s *Server) Put(path string, data map[string]interface{}) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.put(path, data)
+}
+
+func (s *Server) put(path string, data map[string]interface{}) int {
+	s.secrets[path] = append(s.secrets[path], version{data: copyData(data)})
+	return len(s.secrets[path])
+}
+
+func copyData(data map[string]interface{}) map[string]interface{} {
+	copied := make(map[string]interface{}, len(data))
+	for key, value := range data {
+		copied[key] = value
+	}
+	return copied
+}
+
+// PutV1 grava path (sem o mount) no mount KV v1, substituindo os dados anteriores.
+func (s *Server) PutV1(path string, data map[string]interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.secretsV1[path] = copyData(data)
+}
+
+// DataV1 devolve os dados de path no mount KV v1, ou nil se não existir.
+func (s *Server) DataV1(path string) map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.secretsV1[path]
+}
+
+// Data devolve os dados da versão atual de path, ou nil se não existir ou estiver deletada.
+func (s *Server) Data(path string) map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current, _ := s.current(path)
+	return current
+}
+
+// Version devolve o número da versão atual de path (0 se não existir).
+func (s *Server) Version(path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.secrets[path])
+}
+
+// FailWrites faz as escritas em path responderem 500, em qualquer um dos mounts.
+func (s *Server) FailWrites(path string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failWrites[path] = true
+}
+
+// AllowWrites desfaz FailWrites.
+func (s *Server) AllowWrites(path string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.failWrites, path)
+}
+
+// InterleaveWrites simula outro cliente gravando data em path logo antes de cada uma
+// das próximas times escritas, de modo que um check-and-set feito com a versão lida falhe.
+func (s *Server) InterleaveWrites(path string, data map[string]interface{}, times int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.interleaved[path] = interleavedWrite{data: data, times: times}
+}
+
+func (s *Server) current(path string) (map[string]interface{}, int) {
+	versions := s.secrets[path]
+	if len(versions) == 0 || versions[len(versions)-1].deleted {
+		return nil, len(versions)
+	}
+	return versions[len(versions)-1].data, len(versions)
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/v1/"), "/")
+
+	switch {
+	case strings.HasPrefix(path, "sys/internal/ui/mounts/"+MountV1):
+		respond(w, http.StatusOK, map[string]interface{}{
+			"type":    "kv",
+			"path":    MountV1 + "/",
+			"options": map[string]interface{}{"version": "1"},
+		})
+	case strings.HasPrefix(path, "sys/internal/ui/mounts/"):
+		respond(w, http.StatusOK, map[string]interface{}{
+			"type":    "kv",
+			"path":    Mount + "/",
+			"options": map[string]interface{}{"version": "2"},
+		})
+	case path == Mount+"/data" || strings.HasPrefix(path, Mount+"/data/"):
+		s.handleData(w, r, strings.TrimPrefix(strings.TrimPrefix(path, Mount+"/data"), "/"))
+	case path == Mount+"/metadata" || strings.HasPrefix(path, Mount+"/metadata/"):
+		s.handleMetadata(w, r, strings.TrimPrefix(strings.TrimPrefix(path, Mount+"/metadata"), "/"))
+	case strings.HasPrefix(path, Mount+"/delete/"):
+		s.handleDeleteVersions(w, r, strings.TrimPrefix(path, Mount+"/delete/"))
+	case path == MountV1 || strings.HasPrefix(path, MountV1+"/"):
+		s.handleV1(w, r, strings.TrimPrefix(strings.TrimPrefix(path, MountV1), "/"))
+	default:
+		fail(w, http.StatusNotFound, "no handler for route '%s'", path)
+	}
+}
+
+func (s *Server) handleData(w http.ResponseWriter, r *http.Request, secret string) {
+	switch r.Method {
+	case http.MethodGet:
+		versions := s.secrets[secret]
+		n := len(versions)
+		if raw := r.URL.Query().Get("version"); raw != "" && raw != "0" {
+			n, _ = strconv.Atoi(raw)
+		}
+		if n < 1 || n > len(versions) || versions[n-1].deleted {
+			fail(w, http.StatusNotFound, "")
+			return
+		}
+		respond(w, http.StatusOK, map[string]interface{}{
+			"data":     versions[n-1].data,
+			"metadata": versionMetadata(n),
+		})
+
+	case http.MethodPost, http.MethodPut, http.MethodPatch:
+		if s.failWrites[secret] {
+			fail(w, http.StatusInternalServerError, "simulated write failure at '%s'", secret)
+			return
+		}
+
+		if pending := s.interleaved[secret]; pending.times > 0 {
+			s.put(secret, pending.data)
+			pending.times--
+			s.interleaved[secret] = pending
+		}
+
+		var body struct {
+			Data    map[string]interface{} `json:"data"`
+			Options map[string]interface{} `json:"options"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			fail(w, http.StatusBadRequest, "invalid body: %v", err)
+			return
+		}
+
+		current, n := s.current(secret)
+		if cas, ok := body.Options["cas"].(float64); ok && int(cas) != n {
+			fail(w, http.StatusBadRequest, "check-and-set parameter did not match the current version")
+			return
+		}
+
+		data := body.Data
+		if r.Method == http.MethodPatch {
+			if current == nil {
+				fail(w, http.StatusNotFound, "")
+				return
+			}
+			data = make(map[string]interface{})
+			for key, value := range current {
+				data[key] = value
+			}
+			for key, value := range body.Data {
+				data[key] = value
+			}
+		}
+
+		respond(w, http.StatusOK, versionMetadata(s.put(secret, data)))
+
+	default:
+		fail(w, http.StatusMethodNotAllowed, "")
+	}
+}
+
+func (s *Server) handleV1(w http.ResponseWriter, r *http.Request, secret string) {
+	switch {
+	case r.Method == http.MethodGet && r.URL.Query().Get("list") == "true":
+		keys := list(s.secretsV1, secret)
+		if len(keys) == 0 {
+			fail(w, http.StatusNotFound, "")
+			return
+		}
+		respond(w, http.StatusOK, map[string]interface{}{"keys": keys})
+
+	case r.Method == http.MethodGet:
+		data, ok := s.secretsV1[secret]
+		if !ok {
+			fail(w, http.StatusNotFound, "")
+			return
+		}
+		respond(w, http.StatusOK, data)
+
+	case r.Method == http.MethodPost || r.Method == http.MethodPut:
+		if s.failWrites[secret] {
+			fail(w, http.StatusInternalServerError, "simulated write failure at '%s'", secret)
+			return
+		}
+
+		var data map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+			fail(w, http.StatusBadRequest, "invalid body: %v", err)
+			return
+		}
+		s.secretsV1[secret] = data
+		w.WriteHeader(http.StatusNoContent)
+
+	case r.Method == http.MethodDelete:
+		delete(s.secretsV1, secret)
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		fail(w, http.StatusMethodNotAllowed, "")
+	}
+}
+
+func (s *Server) handleMetadata(w http.ResponseWriter, r *http.Request, secret string) {
+	switch {
+	case r.Method == http.MethodGet && r.URL.Query().Get("list") == "true":
+		keys := list(s.secrets, secret)
+		if len(keys) == 0 {
+			fail(w, http.StatusNotFound, "")
+			return
+		}
+		respond(w, http.StatusOK, map[string]interface{}{"keys": keys})
+
+	case r.Method == http.MethodGet:
+		versions := s.secrets[secret]
+		if len(versions) == 0 {
+			fail(w, http.StatusNotFound, "")
+			return
+		}
+		all := make(map[string]interface{})
+		for i := range versions {
+			all[strconv.Itoa(i+1)] = versionMetadata(i + 1)
+		}
+		respond(w, http.StatusOK, map[string]interface{}{
+			"current_version": len(versions),
+			"versions":        all,
+		})
+
+	case r.Method == http.MethodDelete:
+		delete(s.secrets, secret)
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		fail(w, http.StatusMethodNotAllowed, "")
+	}
+}
+
+func (s *Server) handleDeleteVersions(w http.ResponseWriter, r *http.Request, secret string) {
+	var body struct {
+		Versions []int `json:"versions"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		fail(w, http.StatusBadRequest, "invalid body: %v", err)
+		return
+	}
+
+	versions := s.secrets[secret]
+	for _, n := range body.Versions {
+		if n >= 1 && n <= len(versions) {
+			versions[n-1].deleted = true
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// list devolve os filhos imediatos de dir, com "/" no fim dos subdiretórios.
+func list[V any](secrets map[string]V, dir string) []string {
+	prefix := ""
+	if dir != "" {
+		prefix = dir + "/"
+	}
+
+	seen := make(map[string]bool)
+	for secret := range secrets {
+		if !strings.HasPrefix(secret, prefix) {
+			continue
+		}
+		rest := strings.TrimPrefix(secret, prefix)
+		if i := strings.Index(rest, "/"); i >= 0 {
+			rest = rest[:i+1]
+		}
+		seen[rest] = true
+	}
+
+	keys := make([]string, 0, len(seen))
+	for key := range seen {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func versionMetadata(n int) map[string]interface{} {
+	return map[string]interface{}{
+		"version":       n,
+		"created_time":  time.Now().UTC().Format(time.RFC3339),
+		"deletion_time": "",
+		"destroyed":     false,
+	}
+}
+
+func respond(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{"data": data})
+}
+
+func fail(w http.ResponseWriter, status int, format string, args ...interface{}) {
+	errors := []string{}
+	if format != "" {
+		errors = append(errors, fmt.Sprintf(format, args...))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{"errors": errors})
+}
@@ -0,0 +1,179 @@
+package vault
+
+import (
+	"context"
+	"devops-go-vault-api/config"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/vault/api"
+	"golang.org/x/time/rate"
+)
+
+const defaultWalkWorkers = 8
+
+// WalkFunc é chamada para cada segredo encontrado, possivelmente em paralelo.
+// Retornar um erro interrompe a varredura.
+type WalkFunc func(ctx context.Context, secret KVPath) error
+
+type Walker struct {
+	Client *api.Client
+
+	// Workers limita quantas listagens/visitas rodam ao mesmo tempo.
+	Workers int
+
+	// RateLimit limita as requisições ao Vault por segundo; 0 desativa o limite. Vale
+	// para cada chamada ao Vault, inclusive as feitas dentro de visit via waitLimit. Se
+	// o ctx de Walk já tiver um limite (WithRateLimit), a varredura usa o mesmo.
+	RateLimit float64
+
+	// OnListError recebe falhas ao listar diretórios; se nil, o diretório é ignorado.
+	OnListError func(dir KVPath, err error)
+
+	// Filter ignora caminhos excluídos, não incluídos ou além de MaxDepth.
+	Filter PathFilter
+
+	Observer Observer
+}
+
+type limiterKey struct{}
+
+// WithRateLimit prepara ctx para uma operação em lote: todas as chamadas ao Vault feitas
+// com o ctx devolvido, inclusive as varreduras iniciadas a partir dele, dividem o mesmo
+// limite de VAULT_WALK_RATE_LIMIT. Se ctx já tiver um limite, ele é mantido.
+func WithRateLimit(ctx context.Context) context.Context {
+	return withRateLimit(ctx, config.VaultWalkRateLimit)
+}
+
+func withRateLimit(ctx context.Context, perSecond float64) context.Context {
+	if _, ok := ctx.Value(limiterKey{}).(*rate.Limiter); ok {
+		return ctx
+	}
+
+	limiter := rate.NewLimiter(rate.Inf, 0)
+	if perSecond > 0 {
+		limiter = rate.NewLimiter(rate.Limit(perSecond), 1)
+	}
+	return context.WithValue(ctx, limiterKey{}, limiter)
+}
+
+// waitLimit aguarda uma vaga no rate limit da operação em andamento, se houver. Toda
+// chamada ao Vault feita com um ctx de WithRateLimit ou de uma visita deve passar por aqui.
+func waitLimit(ctx context.Context) error {
+	if limiter, ok := ctx.Value(limiterKey{}).(*rate.Limiter); ok {
+		return limiter.Wait(ctx)
+	}
+	return ctx.Err()
+}
+
+func NewWalker(client *api.Client) *Walker {
+	return &Walker{
+		Client:    client,
+		Workers:   config.VaultWalkWorkers,
+		RateLimit: config.VaultWalkRateLimit,
+	}
+}
+
+func (w *Walker) Walk(ctx context.Context, root KVPath, visit WalkFunc) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	workers := w.Workers
+	if workers <= 0 {
+		workers = defaultWalkWorkers
+	}
+
+	ctx = withRateLimit(ctx, w.RateLimit)
+
+	sem := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+	var errOnce sync.Once
+	var walkErr error
+
+	fail := func(err error) {
+		errOnce.Do(func() {
+			walkErr = err
+			cancel()
+		})
+	}
+
+	// acquire reserva um worker; false indica cancelamento
+	acquire := func() bool {
+		select {
+		case sem <- struct{}{}:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	// depth é o nível de dir abaixo de root (0 para o próprio root)
+	var walkDir func(dir KVPath, depth int)
+	walkDir = func(dir KVPath, depth int) {
+		defer wg.Done()
+
+		if !acquire() {
+			return
+		}
+		if err := waitLimit(ctx); err != nil {
+			<-sem
+			return
+		}
+		keys, err := listKeysWithContext(ctx, w.Client, dir)
+		<-sem
+
+		if err != nil {
+			if ctx.Err() == nil {
+				emit(w.Observer, Event{Type: EventListFailed, Path: dir.ListPath(), Error: err.Error()})
+				if w.OnListError != nil {
+					w.OnListError(dir, err)
+				}
+			}
+			return
+		}
+
+		if len(keys) > 0 {
+			emit(w.Observer, Event{Type: EventScanDir, Path: dir.ListPath(), Count: len(keys)})
+		}
+
+		for _, key := range keys {
+			child := dir.Child(key)
+			isDir := strings.HasSuffix(key, "/")
+
+			if w.Filter.excludes(child) ||
+				(isDir && !w.Filter.descends(depth+1)) ||
+				(!isDir && !w.Filter.includes(child)) {
+				continue
+			}
+
+			wg.Add(1)
+			if isDir {
+				go walkDir(child, depth+1)
+				continue
+			}
+
+			go func(secret KVPath) {
+				defer wg.Done()
+
+				if !acquire() {
+					return
+				}
+				defer func() { <-sem }()
+
+				emit(w.Observer, Event{Type: EventCheckSecret, Path: secret.DataPath()})
+				if err := visit(ctx, secret); err != nil {
+					fail(err)
+				}
+			}(child)
+		}
+	}
+
+	wg.Add(1)
+	walkDir(root, 0)
+	wg.Wait()
+
+	if walkErr != nil {
+		return walkErr
+	}
+	return ctx.Err()
+}
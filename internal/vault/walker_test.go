@@ -0,0 +1,35 @@
+package vault
+
+import (
+	"context"
+	"testing"
+
+	"golang.org/x/time/rate"
+)
+
+func TestWithRateLimitKeepsExistingLimiter(t *testing.T) {
+	ctx := withRateLimit(context.Background(), 5)
+	limiter := ctx.Value(limiterKey{}).(*rate.Limiter)
+
+	// Uma varredura iniciada dentro da operação divide o mesmo limite
+	nested := withRateLimit(ctx, 100)
+	if nested.Value(limiterKey{}).(*rate.Limiter) != limiter {
+		t.Error("nested operation created its own limiter")
+	}
+	if limiter.Limit() != 5 {
+		t.Errorf("limit = %v, want 5", limiter.Limit())
+	}
+
+	if unlimited := withRateLimit(context.Background(), 0).Value(limiterKey{}).(*rate.Limiter); unlimited.Limit() != rate.Inf {
+		t.Errorf("limit = %v, want no limit", unlimited.Limit())
+	}
+}
+
+func TestWaitLimitHonorsCancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(WithRateLimit(context.Background()))
+	cancel()
+
+	if err := waitLimit(ctx); err == nil {
+		t.Error("waitLimit succeeded on a canceled context")
+	}
+}